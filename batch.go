@@ -0,0 +1,341 @@
+package gremgo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/ONSdigital/graphson"
+	"github.com/ONSdigital/gremgo-neptune/traversal"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrorBatchData         = errors.New("batch data must be a slice of structs (or of pointers to structs)")
+	ErrorBatchItemTooLarge = errors.New("element exceeds the maximum script size of a batch")
+	ErrorBatchFailed       = errors.New("failed to add some elements of the batch")
+)
+
+const (
+	defaultBatchMaxElements   = 50
+	defaultBatchMaxScriptSize = 256 * 1024
+	defaultBatchConcurrency   = 4
+
+	// batchScriptPrefix starts the script of each batch, whose elements are added by the child traversals of union()
+	batchScriptPrefix = "g.inject(0).union("
+)
+
+// BatchLimits splits the elements added by AddVerticesCtx and AddEdgesCtx into requests,
+// zero values being the defaults
+type BatchLimits struct {
+	MaxElements   int // the number of elements added per request (default 50)
+	MaxScriptSize int // the size in bytes of the script of each request (default 256KiB)
+	Concurrency   int // the number of requests in flight at once (default 4)
+}
+
+func (l BatchLimits) withDefaults() BatchLimits {
+	if l.MaxElements <= 0 {
+		l.MaxElements = defaultBatchMaxElements
+	}
+	if l.MaxScriptSize <= 0 {
+		l.MaxScriptSize = defaultBatchMaxScriptSize
+	}
+	if l.Concurrency <= 0 {
+		l.Concurrency = defaultBatchConcurrency
+	}
+	return l
+}
+
+// VertexResult is the vertex added for an item of AddVerticesCtx, or the error which prevented it
+type VertexResult struct {
+	Vertex graphson.Vertex
+	Err    error
+}
+
+// EdgeResult is the edge added for an item of AddEdgesCtx, or the error which prevented it
+type EdgeResult struct {
+	Edge graphson.Edge
+	Err  error
+}
+
+// batchExecFunc executes the script of a batch
+type batchExecFunc func(ctx context.Context, query string) ([]Response, error)
+
+// batchItems returns the items of data, a slice (or array)
+func batchItems(data interface{}) ([]interface{}, error) {
+	d := reflect.ValueOf(data)
+	if !isList(d) {
+		return nil, ErrorBatchData
+	}
+	items := make([]interface{}, d.Len())
+	for i := range items {
+		items[i] = d.Index(i).Interface()
+	}
+	return items, nil
+}
+
+// batchScripts groups the child traversals (fragments, "" for an item which has already failed) into the scripts
+// of requests within limits, returning the items (indexes) of each, and setting the errors of any items too large
+func batchScripts(limits BatchLimits, fragments []string, errs []error) (scripts []string, items [][]int) {
+	var b strings.Builder
+	var batch []int
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		b.WriteByte(')')
+		scripts = append(scripts, b.String())
+		items = append(items, batch)
+		b.Reset()
+		batch = nil
+	}
+	for i, frag := range fragments {
+		if frag == "" {
+			continue
+		}
+		if len(batchScriptPrefix)+len(frag)+1 > limits.MaxScriptSize {
+			errs[i] = errors.Wrapf(ErrorBatchItemTooLarge, "%d bytes", len(frag))
+			continue
+		}
+		if len(batch) == limits.MaxElements || b.Len()+len(frag)+2 > limits.MaxScriptSize {
+			flush()
+		}
+		if len(batch) == 0 {
+			b.WriteString(batchScriptPrefix)
+		} else {
+			b.WriteByte(',')
+		}
+		b.WriteString(frag)
+		batch = append(batch, i)
+	}
+	flush()
+	return
+}
+
+// runBatches adds the elements of fragments (see batchScripts) in batches, executing up to limits.Concurrency
+// requests at once, and calling collect with the items (indexes) and responses of each batch.
+// If a request fails, the error is set for each of its items.
+func runBatches(ctx context.Context, limits BatchLimits, fragments []string, errs []error, exec batchExecFunc, collect func(items []int, resp []Response) error) error {
+	limits = limits.withDefaults()
+	scripts, items := batchScripts(limits, fragments, errs)
+
+	sem := make(chan struct{}, limits.Concurrency)
+	var wg sync.WaitGroup
+	for i := range scripts {
+		script, batch := scripts[i], items[i]
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			setBatchErr(errs, batch, ctx.Err())
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			resp, err := exec(ctx, script)
+			if err == nil {
+				err = collect(batch, resp)
+			}
+			if err != nil {
+				setBatchErr(errs, batch, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return errors.Wrapf(ErrorBatchFailed, "%d of %d elements", failed, len(errs))
+	}
+	return nil
+}
+
+func setBatchErr(errs []error, items []int, err error) {
+	for _, i := range items {
+		errs[i] = err
+	}
+}
+
+// batchIndexSteps returns the steps which project the element added by the child traversal for item i
+// as a map of the item (index) "i" and element "e", as union() does not promise the order of its results
+func batchIndexSteps(i int) string {
+	return fmt.Sprintf(".project('i','e').by(__.constant(%d)).by()", i)
+}
+
+// batchResults decodes the results of the items (indexes) of a batch (see batchIndexSteps),
+// returning the element of each item in the order of batch
+func batchResults(batch []int, resp []Response, elements string) ([]interface{}, error) {
+	results, err := DecodeResponses(resp)
+	if err != nil {
+		return nil, err
+	}
+	maps, err := results.Maps()
+	if err != nil {
+		return nil, err
+	}
+	if len(maps) != len(batch) {
+		return nil, fmt.Errorf("should receive %d %s, got %d", len(batch), elements, len(maps))
+	}
+	pending := make(map[int]int, len(batch))
+	for j, i := range batch {
+		pending[i] = j
+	}
+	elems := make([]interface{}, len(batch))
+	for _, m := range maps {
+		i, err := m.GetInt64("i")
+		if err != nil {
+			return nil, err
+		}
+		j, ok := pending[int(i)]
+		if !ok {
+			return nil, fmt.Errorf("unexpected result for item %d", i)
+		}
+		delete(pending, int(i))
+		elems[j], _ = m.Get("e")
+	}
+	return elems, nil
+}
+
+// addVertices adds the vertices for the items of data (see AddVerticesCtx) with exec
+func addVertices(ctx context.Context, label string, data interface{}, limits BatchLimits, exec batchExecFunc) (res []VertexResult, err error) {
+	var items []interface{}
+	if items, err = batchItems(data); err != nil {
+		return
+	}
+	fragments := make([]string, len(items))
+	errs := make([]error, len(items))
+	for i, item := range items {
+		q, _, err := GremlinForVertex(label, item)
+		if err != nil && err != ErrorNoGraphTags {
+			errs[i] = err
+			continue
+		}
+		fragments[i] = "__." + q + batchIndexSteps(i)
+	}
+
+	res = make([]VertexResult, len(items))
+	err = runBatches(ctx, limits, fragments, errs, exec, func(batch []int, resp []Response) error {
+		elems, err := batchResults(batch, resp, "vertices")
+		if err != nil {
+			return errors.Wrap(err, "AddVerticesCtx")
+		}
+		// only set the results once the whole batch has decoded, as an error is set for all of its items
+		vertices := make([]graphson.Vertex, len(batch))
+		for j, elem := range elems {
+			v, ok := elem.(graphson.Vertex)
+			if !ok {
+				return errors.Wrap(unexpectedType("g:Vertex", elem), "AddVerticesCtx")
+			}
+			vertices[j] = v
+		}
+		for j, i := range batch {
+			res[i].Vertex = vertices[j]
+		}
+		return nil
+	})
+	for i := range res {
+		res[i].Err = errs[i]
+	}
+	return
+}
+
+// addEdges adds the edges for the items of data (see AddEdgesCtx) with exec
+func addEdges(ctx context.Context, label string, data interface{}, limits BatchLimits, exec batchExecFunc) (res []EdgeResult, err error) {
+	var items []interface{}
+	if items, err = batchItems(data); err != nil {
+		return
+	}
+	fragments := make([]string, len(items))
+	errs := make([]error, len(items))
+	addE := traversal.Anon().AddE
+	for i, item := range items {
+		t, err := edgeTraversal(addE, label, item)
+		if err == nil {
+			if fragments[i], err = t.Groovy(); err == nil {
+				fragments[i] += batchIndexSteps(i)
+			}
+		}
+		errs[i] = err
+	}
+
+	res = make([]EdgeResult, len(items))
+	err = runBatches(ctx, limits, fragments, errs, exec, func(batch []int, resp []Response) error {
+		elems, err := batchResults(batch, resp, "edges")
+		if err != nil {
+			return errors.Wrap(err, "AddEdgesCtx")
+		}
+		// only set the results once the whole batch has decoded, as an error is set for all of its items
+		edges := make([]graphson.Edge, len(batch))
+		for j, elem := range elems {
+			e, ok := elem.(graphson.Edge)
+			if !ok {
+				return errors.Wrap(unexpectedType("g:Edge", elem), "AddEdgesCtx")
+			}
+			edges[j] = e
+		}
+		for j, i := range batch {
+			res[i].Edge = edges[j]
+		}
+		return nil
+	})
+	for i := range res {
+		res[i].Err = errs[i]
+	}
+	return
+}
+
+// AddVerticesCtx adds a vertex with label for each item of data, a slice of structs with `graph` tags (as for
+// AddVertexCtx), returning the result for each item in order. The vertices are added by requests of up to
+// limits.MaxElements, executed limits.Concurrency at a time, and the items of a failed request share its error.
+// If any item fails, the error is ErrorBatchFailed.
+func (c *Client) AddVerticesCtx(ctx context.Context, label string, data interface{}, limits BatchLimits) (res []VertexResult, err error) {
+	if c.conn.IsDisposed() {
+		return nil, ErrorConnectionDisposed
+	}
+	return addVertices(ctx, label, data, limits, func(ctx context.Context, query string) ([]Response, error) {
+		return c.ExecuteCtx(ctx, query, nil, nil)
+	})
+}
+
+// AddEdgesCtx adds an edge for each item of data, a slice of structs with `graph` tags (see GremlinForEdge),
+// returning the result for each item in order, with requests as for AddVerticesCtx
+func (c *Client) AddEdgesCtx(ctx context.Context, label string, data interface{}, limits BatchLimits) (res []EdgeResult, err error) {
+	if c.conn.IsDisposed() {
+		return nil, ErrorConnectionDisposed
+	}
+	return addEdges(ctx, label, data, limits, func(ctx context.Context, query string) ([]Response, error) {
+		return c.ExecuteCtx(ctx, query, nil, nil)
+	})
+}
+
+// batchExecCtx executes the script of a batch on a connection of the pool
+func (p *Pool) batchExecCtx(ctx context.Context, query string) (resp []Response, err error) {
+	var pc *conn
+	if pc, err = p.connCtx(ctx); err != nil {
+		return nil, errors.Wrap(err, "batchExecCtx: Failed p.connCtx")
+	}
+	defer func() {
+		p.putConn(pc, err)
+	}()
+	return pc.Client.ExecuteCtx(ctx, query, nil, nil)
+}
+
+// AddVerticesCtx adds a vertex for each item of data (see Client.AddVerticesCtx), with the requests spread over the pool
+func (p *Pool) AddVerticesCtx(ctx context.Context, label string, data interface{}, limits BatchLimits) (res []VertexResult, err error) {
+	return addVertices(ctx, label, data, limits, p.batchExecCtx)
+}
+
+// AddEdgesCtx adds an edge for each item of data (see Client.AddEdgesCtx), with the requests spread over the pool
+func (p *Pool) AddEdgesCtx(ctx context.Context, label string, data interface{}, limits BatchLimits) (res []EdgeResult, err error) {
+	return addEdges(ctx, label, data, limits, p.batchExecCtx)
+}
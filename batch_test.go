@@ -0,0 +1,187 @@
+package gremgo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type batchVertex struct {
+	Id   string
+	Name string `graph:"name,string"`
+}
+
+var (
+	batchIDRegexp    = regexp.MustCompile(`property\(id,'([^']*)'\)`)
+	batchIndexRegexp = regexp.MustCompile(`\.by\(__\.constant\((\d+)\)\)`)
+)
+
+// batchServer returns an exec which responds to each script with the item index and vertex for each id of its
+// addV steps, in reverse order (failing scripts which contain "fail"), recording the scripts and the maximum
+// number executing at once
+type batchServer struct {
+	sync.Mutex
+	scripts  []string
+	inFlight int
+	maxIn    int
+}
+
+func (s *batchServer) exec(ctx context.Context, query string) ([]Response, error) {
+	s.Lock()
+	s.scripts = append(s.scripts, query)
+	s.inFlight++
+	if s.inFlight > s.maxIn {
+		s.maxIn = s.inFlight
+	}
+	s.Unlock()
+	time.Sleep(5 * time.Millisecond)
+	defer func() {
+		s.Lock()
+		s.inFlight--
+		s.Unlock()
+	}()
+
+	if strings.Contains(query, "fail") {
+		return nil, errors.New("server error")
+	}
+	ids := batchIDRegexp.FindAllStringSubmatch(query, -1)
+	indexes := batchIndexRegexp.FindAllStringSubmatch(query, -1)
+	var results []string
+	for j := len(ids) - 1; j >= 0; j-- {
+		results = append(results, fmt.Sprintf(`{"@type":"g:Map","@value":["i",{"@type":"g:Int32","@value":%s},`+
+			`"e",{"@type":"g:Vertex","@value":{"id":%q,"label":"dataset"}}]}`, indexes[j][1], ids[j][1]))
+	}
+	data := json.RawMessage(`{"@type":"g:List","@value":[` + strings.Join(results, ",") + `]}`)
+	return []Response{{Status: Status{Code: StatusSuccess}, Result: Result{Data: data}}}, nil
+}
+
+func TestBatchScripts(t *testing.T) {
+	fragments := []string{"__.addV('a')", "", "__.addV('b')", "__.addV('c')", strings.Repeat("x", 100), "__.addV('d')"}
+	errs := make([]error, len(fragments))
+	scripts, items := batchScripts(BatchLimits{MaxElements: 2, MaxScriptSize: 50}, fragments, errs)
+
+	expected := []string{
+		"g.inject(0).union(__.addV('a'),__.addV('b'))",
+		"g.inject(0).union(__.addV('c'),__.addV('d'))",
+	}
+	if fmt.Sprint(scripts) != fmt.Sprint(expected) || fmt.Sprint(items) != "[[0 2] [3 5]]" {
+		t.Errorf("Expected %v [[0 2] [3 5]], got %v %v", expected, scripts, items)
+	}
+	if !errors.Is(errs[4], ErrorBatchItemTooLarge) {
+		t.Errorf("Expected %v, got %v", ErrorBatchItemTooLarge, errs[4])
+	}
+
+	scripts, _ = batchScripts(BatchLimits{MaxElements: 10, MaxScriptSize: 40}, fragments[:3], make([]error, 3))
+	if len(scripts) != 2 {
+		t.Errorf("Expected the script size to split 2 scripts, got %v", scripts)
+	}
+}
+
+func TestAddVertices(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	data := make([]batchVertex, 95)
+	for i := range data {
+		data[i] = batchVertex{Id: fmt.Sprintf("v%d", i), Name: "x"}
+	}
+	data[60].Id = "fail"
+
+	s := &batchServer{}
+	res, err := addVertices(ctx, "dataset", data, BatchLimits{MaxElements: 10, Concurrency: 3}, s.exec)
+	if !errors.Is(err, ErrorBatchFailed) {
+		t.Errorf("Expected %v, got %v", ErrorBatchFailed, err)
+	}
+	if len(res) != len(data) {
+		t.Fatalf("Expected %d results, got %d", len(data), len(res))
+	}
+	for i, r := range res {
+		if i >= 60 && i < 70 {
+			if r.Err == nil {
+				t.Errorf("Expected the error of the failed batch for item %d", i)
+			}
+		} else if r.Err != nil || r.Vertex.GetID() != data[i].Id {
+			t.Errorf("Expected vertex %s for item %d, got %q, %v", data[i].Id, i, r.Vertex.GetID(), r.Err)
+		}
+	}
+	if len(s.scripts) != 10 || s.maxIn > 3 {
+		t.Errorf("Expected 10 requests, at most 3 at once, got %d, %d", len(s.scripts), s.maxIn)
+	}
+
+	if _, err = addVertices(ctx, "dataset", batchVertex{}, BatchLimits{}, s.exec); err != ErrorBatchData {
+		t.Errorf("Expected %v, got %v", ErrorBatchData, err)
+	}
+}
+
+func TestAddVerticesBadResult(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// the first item decodes, but the second is not a vertex, so the batch fails
+	exec := func(ctx context.Context, query string) ([]Response, error) {
+		data := json.RawMessage(`{"@type":"g:List","@value":[` +
+			`{"@type":"g:Map","@value":["i",{"@type":"g:Int32","@value":0},"e",{"@type":"g:Vertex","@value":{"id":"a","label":"dataset"}}]},` +
+			`{"@type":"g:Map","@value":["i",{"@type":"g:Int32","@value":1},"e","b"]}]}`)
+		return []Response{{Status: Status{Code: StatusSuccess}, Result: Result{Data: data}}}, nil
+	}
+	res, err := addVertices(ctx, "dataset", []batchVertex{{Id: "a"}, {Id: "b"}}, BatchLimits{}, exec)
+	if !errors.Is(err, ErrorBatchFailed) {
+		t.Errorf("Expected %v, got %v", ErrorBatchFailed, err)
+	}
+	for i, r := range res {
+		if r.Err == nil || r.Vertex.GetID() != "" {
+			t.Errorf("Expected only an error for item %d, got %q, %v", i, r.Vertex.GetID(), r.Err)
+		}
+	}
+}
+
+func TestAddEdges(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var scripts []string
+	exec := func(ctx context.Context, query string) ([]Response, error) {
+		scripts = append(scripts, query)
+		data := json.RawMessage(`{"@type":"g:List","@value":[{"@type":"g:Map","@value":["i",{"@type":"g:Int32","@value":0},"e",` +
+			`{"@type":"g:Edge","@value":{"id":"e1","label":"hasEdition","inVLabel":"edition","outVLabel":"dataset","inV":"cpih-2020","outV":"cpih"}}]}]}`)
+		return []Response{{Status: Status{Code: StatusSuccess}, Result: Result{Data: data}}}, nil
+	}
+	data := []*hasEdition{{Dataset: "cpih", Edition: "cpih-2020"}, {Dataset: "cpih"}}
+	res, err := addEdges(ctx, "hasEdition", data, BatchLimits{}, exec)
+	if !errors.Is(err, ErrorBatchFailed) {
+		t.Errorf("Expected %v, got %v", ErrorBatchFailed, err)
+	}
+	if len(res) != 2 || res[0].Err != nil || res[0].Edge.Value.ID != "e1" || res[1].Err != ErrorEdgeVertices {
+		t.Errorf("Unexpected results %+v", res)
+	}
+	expected := "g.inject(0).union(__.addE('hasEdition').from(__.V('cpih')).to(__.V('cpih-2020')).property('since',0).property('score',0d)" +
+		".project('i','e').by(__.constant(0)).by())"
+	if len(scripts) != 1 || scripts[0] != expected {
+		t.Errorf("Expected\n%s\ngot\n%v", expected, scripts)
+	}
+}
+
+func TestAddVerticesCtx(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c := newChunksClient()
+	sent := sentRequest(t, c)
+	_, err := c.AddVerticesCtx(ctx, "dataset", []batchVertex{{Id: "a"}, {Id: "b"}}, BatchLimits{})
+	if err == nil {
+		t.Error("Expected an error for the vertices missing from the response")
+	}
+	req := <-sent
+	expected := "g.inject(0).union(__.addV('dataset').property(id,'a').project('i','e').by(__.constant(0)).by()," +
+		"__.addV('dataset').property(id,'b').project('i','e').by(__.constant(1)).by())"
+	if q := req.Args["gremlin"]; q != expected {
+		t.Errorf("Unexpected script %v", q)
+	}
+	assertNoRequestState(t, c)
+}
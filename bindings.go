@@ -0,0 +1,269 @@
+package gremgo
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/ONSdigital/gremgo-neptune/traversal"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrorInvalidBinding        = errors.New("invalid binding")
+	ErrorRebindingsUnsupported = errors.New("rebindings cannot be interpolated")
+)
+
+var bindingNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Bindings are the (typed) values of the variables of a parameterised query, e.g. Bindings{"x": 10} for `g.V().has('n', x)`.
+// Values may be strings, booleans, numbers, nil, time.Time, slices of these, or predicates and tokens from the
+// traversal package.
+type Bindings map[string]interface{}
+
+// BindingMode sets how the bindings of a query are sent to the server
+type BindingMode int
+
+const (
+	// BindingModeServer sends the bindings to the server with the query (for Gremlin Server)
+	BindingModeServer BindingMode = iota
+	// BindingModeInterpolate substitutes the bindings into the query as literals, client-side (for Neptune,
+	// which does not support bindings)
+	BindingModeInterpolate
+)
+
+func stringBindings(bindings map[string]string) Bindings {
+	if bindings == nil {
+		return nil
+	}
+	b := make(Bindings, len(bindings))
+	for k, v := range bindings {
+		b[k] = v
+	}
+	return b
+}
+
+// prepareQuery packages the query as a request, with the bindings as per the client's BindingMode
+// (and checked by the linter, if set)
+func (c *Client) prepareQuery(query string, bindings Bindings, rebindings map[string]string) (req request, id string, err error) {
+	if c.bindingMode == BindingModeInterpolate {
+		if len(rebindings) > 0 {
+			return req, id, ErrorRebindingsUnsupported
+		}
+		if query, err = InterpolateBindings(query, bindings); err != nil {
+			return
+		}
+		bindings = nil
+	}
+	if err = c.lintQuery(query, bindings); err != nil {
+		return
+	}
+	return prepareRequest(query, bindings, rebindings)
+}
+
+// InterpolateBindings substitutes the bindings into query, as safely quoted Gremlin-Groovy literals.
+// A binding replaces each occurrence of its name as an identifier in the query, other than in strings or
+// comments, as a property (e.g. `x.name`) or as a map key (e.g. `[name: 1]`).
+func InterpolateBindings(query string, bindings Bindings) (string, error) {
+	if len(bindings) == 0 {
+		return query, nil
+	}
+	literals := make(map[string]string, len(bindings))
+	for name, val := range bindings {
+		if !bindingNameRegexp.MatchString(name) {
+			return "", errors.Wrapf(ErrorInvalidBinding, "name %q", name)
+		}
+		lit, err := traversal.Literal(val)
+		if err != nil {
+			return "", errors.Wrapf(err, "binding %q", name)
+		}
+		literals[name] = lit
+	}
+
+	return replaceIdentifiers(query, func(ident string, start, end int) (string, bool) {
+		lit, ok := literals[ident]
+		return lit, ok && !isMemberOrKey(query, start, end)
+	}), nil
+}
+
+// replaceIdentifiers returns query with each identifier query[start:end] (other than in strings or comments)
+// replaced by the result of replace, if it returns true
+func replaceIdentifiers(query string, replace func(ident string, start, end int) (string, bool)) string {
+	var b strings.Builder
+	b.Grow(len(query))
+	for i := 0; i < len(query); {
+		switch {
+		case strings.HasPrefix(query[i:], "//"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			b.WriteString(query[i : i+end])
+			i += end
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				end = len(query) - i
+			} else {
+				end += 4
+			}
+			b.WriteString(query[i : i+end])
+			i += end
+		case query[i] == '\'' || query[i] == '"':
+			end := groovyStringEnd(query, i)
+			b.WriteString(query[i:end])
+			i = end
+		case isIdentStart(query[i]):
+			end := i + 1
+			for end < len(query) && isIdentPart(query[end]) {
+				end++
+			}
+			ident := query[i:end]
+			if repl, ok := replace(ident, i, end); ok {
+				b.WriteString(repl)
+			} else {
+				b.WriteString(ident)
+			}
+			i = end
+		case query[i] >= '0' && query[i] <= '9':
+			// skip numbers (e.g. `1e5`, `10L`), whose suffixes would otherwise be taken for identifiers
+			end := i + 1
+			for end < len(query) && (isIdentPart(query[end]) || query[end] == '.') {
+				end++
+			}
+			b.WriteString(query[i:end])
+			i = end
+		default:
+			b.WriteByte(query[i])
+			i++
+		}
+	}
+	return b.String()
+}
+
+// groovyStringEnd returns the index after the (single, double or triple-quoted) string starting at start
+func groovyStringEnd(query string, start int) int {
+	quote := query[start : start+1]
+	if strings.HasPrefix(query[start:], strings.Repeat(quote, 3)) {
+		quote = strings.Repeat(quote, 3)
+	}
+	for i := start + len(quote); i < len(query); i++ {
+		if query[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.HasPrefix(query[i:], quote) {
+			return i + len(quote)
+		}
+	}
+	return len(query)
+}
+
+// isMemberOrKey returns whether the identifier query[start:end] follows a `.` or precedes a `:` (but not `::`,
+// nor the `:` of a ternary `c ? x : y`)
+func isMemberOrKey(query string, start, end int) bool {
+	before := strings.TrimRight(query[:start], " \t\r\n")
+	if strings.HasSuffix(before, ".") {
+		return true
+	}
+	after := strings.TrimLeft(query[end:], " \t\r\n")
+	return strings.HasPrefix(after, ":") && !strings.HasPrefix(after, "::") && !inTernary(query, start)
+}
+
+// inTernary returns whether query[:end] has a `?` of a ternary without its `:` (in the same brackets),
+// other than in strings or comments
+func inTernary(query string, end int) bool {
+	open := []int{0} // the number of unmatched `?` in each enclosing bracket
+	for i := 0; i < end; {
+		switch c := query[i]; {
+		case strings.HasPrefix(query[i:], "//"):
+			next := strings.IndexByte(query[i:], '\n')
+			if next < 0 {
+				return false
+			}
+			i += next
+			continue
+		case strings.HasPrefix(query[i:], "/*"):
+			next := strings.Index(query[i+2:], "*/")
+			if next < 0 {
+				return false
+			}
+			i += next + 4
+			continue
+		case c == '\'' || c == '"':
+			i = groovyStringEnd(query, i)
+			continue
+		case c == '(' || c == '[' || c == '{':
+			open = append(open, 0)
+		case c == ')' || c == ']' || c == '}':
+			if len(open) > 1 {
+				open = open[:len(open)-1]
+			}
+		case c == ';':
+			open[len(open)-1] = 0
+		case c == '?':
+			if i+1 < len(query) && strings.IndexByte(".:[", query[i+1]) >= 0 {
+				i += 2 // safe navigation `?.` or `?[`, or elvis `?:`
+				continue
+			}
+			open[len(open)-1]++
+		case c == ':':
+			if strings.HasPrefix(query[i:], "::") {
+				i += 2
+				continue
+			}
+			if open[len(open)-1] > 0 {
+				open[len(open)-1]--
+			}
+		}
+		i++
+	}
+	return open[len(open)-1] > 0
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
+
+// ExecuteBindingsCtx sends the query with typed bindings (substituted client-side with BindingModeInterpolate)
+// and returns the responses
+func (c *Client) ExecuteBindingsCtx(ctx context.Context, query string, bindings Bindings, rebindings map[string]string) (resp []Response, err error) {
+	if c.conn.IsDisposed() {
+		return resp, ErrorConnectionDisposed
+	}
+	return c.executeRequestCtx(ctx, query, bindings, rebindings)
+}
+
+// QueryBindingsCtx sends the query with typed bindings (as for ExecuteBindingsCtx) and returns the decoded results
+func (c *Client) QueryBindingsCtx(ctx context.Context, query string, bindings Bindings, rebindings map[string]string) (res List, err error) {
+	var resp []Response
+	if resp, err = c.ExecuteBindingsCtx(ctx, query, bindings, rebindings); err != nil {
+		return
+	}
+	return DecodeResponses(resp)
+}
+
+// ExecuteBindingsCtx sends the query with typed bindings (substituted client-side with BindingModeInterpolate)
+// and returns the responses
+func (p *Pool) ExecuteBindingsCtx(ctx context.Context, query string, bindings Bindings, rebindings map[string]string) (resp []Response, err error) {
+	var pc *conn
+	if pc, err = p.connCtx(ctx); err != nil {
+		return nil, errors.Wrap(err, "ExecuteBindingsCtx: Failed p.connCtx")
+	}
+	defer p.putConn(pc, err)
+	return pc.Client.ExecuteBindingsCtx(ctx, query, bindings, rebindings)
+}
+
+// QueryBindingsCtx sends the query with typed bindings (as for ExecuteBindingsCtx) and returns the decoded results
+func (p *Pool) QueryBindingsCtx(ctx context.Context, query string, bindings Bindings, rebindings map[string]string) (res List, err error) {
+	var pc *conn
+	if pc, err = p.connCtx(ctx); err != nil {
+		return nil, errors.Wrap(err, "QueryBindingsCtx: Failed p.connCtx")
+	}
+	defer p.putConn(pc, err)
+	return pc.Client.QueryBindingsCtx(ctx, query, bindings, rebindings)
+}
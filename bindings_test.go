@@ -0,0 +1,111 @@
+package gremgo
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/gremgo-neptune/traversal"
+	"github.com/pkg/errors"
+)
+
+func TestInterpolateBindings(t *testing.T) {
+	testData := []struct {
+		query    string
+		bindings Bindings
+		expected string
+	}{
+		{"g.V(x)", Bindings{"x": "10"}, "g.V('10')"},
+		{"g.V().has('n', x).limit(n)", Bindings{"x": 10, "n": int64(5)}, "g.V().has('n', 10).limit(5)"},
+		{"g.V().has('n', x)", Bindings{"x": "it's\n${evil}"}, `g.V().has('n', 'it\'s\n${evil}')`},
+		{"g.V().has('x', x).has(\"x\", x2)", Bindings{"x": true}, "g.V().has('x', true).has(\"x\", x2)"},
+		{"g.V().has('a', a).values('a') // a comment with a\n/* a */ a", Bindings{"a": nil}, "g.V().has('a', null).values('a') // a comment with a\n/* a */ null"},
+		{"x.name; [name: name]", Bindings{"name": 1.5}, "x.name; [name: 1.5d]"},
+		{"g.V().has('a\\'', a).has('''a''', a)", Bindings{"a": 1}, "g.V().has('a\\'', 1).has('''a''', 1)"},
+		{"g.V().limit(10L).has('n', L)", Bindings{"L": 2}, "g.V().limit(10L).has('n', 2)"},
+		{"g.V().has('n', p)", Bindings{"p": traversal.Within("a", "b")}, "g.V().has('n', P.within('a','b'))"},
+		{"g.V().has('at', t)", Bindings{"t": time.Unix(0, 0)}, "g.V().has('at', datetime('1970-01-01T00:00:00Z'))"},
+		{"g.V(c ? x : y)", Bindings{"c": true, "x": 1, "y": 2}, "g.V(true ? 1 : 2)"},
+		{"c ? [x: x] : a?.x ?: [y: y]", Bindings{"c": true, "x": 1, "y": 2}, "true ? [x: 1] : a?.x ?: [y: 2]"},
+		{"g.V(x)", nil, "g.V(x)"},
+	}
+	for _, td := range testData {
+		got, err := InterpolateBindings(td.query, td.bindings)
+		if err != nil {
+			t.Errorf("%s: %v", td.query, err)
+			continue
+		}
+		if got != td.expected {
+			t.Errorf("%s: expected %s, got %s", td.query, td.expected, got)
+		}
+	}
+
+	if _, err := InterpolateBindings("g.V(x)", Bindings{"x;drop()": 1}); errors.Cause(err) != ErrorInvalidBinding {
+		t.Errorf("Expected %v, got %v", ErrorInvalidBinding, err)
+	}
+	if _, err := InterpolateBindings("g.V(x)", Bindings{"x": struct{}{}}); errors.Cause(err) != traversal.ErrorUnsupportedArgument {
+		t.Errorf("Expected %v, got %v", traversal.ErrorUnsupportedArgument, err)
+	}
+}
+
+// sentRequest returns the next request sent by the client, replying to it with an empty response
+func sentRequest(t *testing.T, c *Client) <-chan request {
+	sent := make(chan request, 1)
+	go func() {
+		msg := <-c.requests
+		var req request
+		if err := json.Unmarshal(msg[len(mimeTypePrefix):], &req); err != nil {
+			t.Error(err)
+		}
+		sent <- req
+		c.saveResponse(Response{RequestID: req.RequestID, Status: Status{Code: StatusNoContent}}, nil)
+	}()
+	return sent
+}
+
+func TestBindingMode(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	bindings := Bindings{"x": "a'b", "n": 10, "f": 0.5}
+
+	c := newChunksClient()
+	sent := sentRequest(t, c)
+	if _, err := c.ExecuteBindingsCtx(ctx, "g.V().has('k', x).has('n', n).has('f', f)", bindings, nil); err != nil {
+		t.Fatal(err)
+	}
+	req := <-sent
+	expected := map[string]interface{}{
+		"x": "a'b",
+		"n": map[string]interface{}{"@type": "g:Int32", "@value": 10.0},
+		"f": map[string]interface{}{"@type": "g:Double", "@value": 0.5},
+	}
+	if req.Args["gremlin"] != "g.V().has('k', x).has('n', n).has('f', f)" || !reflect.DeepEqual(req.Args["bindings"], expected) {
+		t.Errorf("Expected bindings to be sent to the server, got %+v", req.Args)
+	}
+
+	c = newChunksClient()
+	SetBindingMode(BindingModeInterpolate)(c)
+	sent = sentRequest(t, c)
+	if _, err := c.QueryBindingsCtx(ctx, "g.V().has('k', x).has('n', n).has('f', f)", bindings, nil); err != nil {
+		t.Fatal(err)
+	}
+	req = <-sent
+	if _, ok := req.Args["bindings"]; ok || req.Args["gremlin"] != `g.V().has('k', 'a\'b').has('n', 10).has('f', 0.5d)` {
+		t.Errorf("Expected bindings to be interpolated, got %+v", req.Args)
+	}
+
+	sent = sentRequest(t, c)
+	if _, err := c.ExecuteCtx(ctx, "g.V(x)", map[string]string{"x": "10"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if req = <-sent; req.Args["gremlin"] != "g.V('10')" {
+		t.Errorf("Expected string bindings to be interpolated, got %+v", req.Args)
+	}
+
+	if _, err := c.ExecuteCtx(ctx, "g.V(x)", nil, map[string]string{"g": "g1"}); err != ErrorRebindingsUnsupported {
+		t.Errorf("Expected %v, got %v", ErrorRebindingsUnsupported, err)
+	}
+	assertNoRequestState(t, c)
+}
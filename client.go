@@ -1,17 +1,18 @@
 package gremgo
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"reflect"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/ONSdigital/graphson"
+	"github.com/ONSdigital/gremgo-neptune/traversal"
 	"github.com/gorilla/websocket"
 	"github.com/pkg/errors"
 )
@@ -20,6 +21,13 @@ var (
 	ErrorConnectionDisposed      = errors.New("you cannot write on a disposed connection")
 	ErrorNoGraphTags             = errors.New("does not contain any graph tags")
 	ErrorUnsupportedPropertyType = errors.New("unsupported property map value type")
+	ErrorResponseTimeout         = errors.New("timed out waiting for response")
+	ErrorUnknownCursor           = errors.New("unknown cursor: not opened by this client, already read to the end, or long expired")
+	ErrorNoAuthCredentials       = errors.New("you must create a Secure Dialer for authenticating with the server")
+	ErrorNotStruct               = errors.New("data must be a struct or a pointer to a struct")
+	ErrorCursorBufferFull        = errors.New("cursor buffer full: results not read quickly enough")
+	ErrorResponseTooLarge        = errors.New("response too large")
+	ErrorLint                    = errors.New("query not supported by Neptune")
 	DefaultDialer                = websocket.Dialer{
 		WriteBufferSize:  512 * 1024,
 		ReadBufferSize:   512 * 1024,
@@ -35,6 +43,19 @@ type Client struct {
 	results          *sync.Map
 	responseNotifier *sync.Map // responseNotifier notifies the requester that a response has been completed for the request
 	chunkNotifier    *sync.Map // chunkNotifier contains channels per requestID (if using cursors) which notifies the requester that a partial response has arrived
+	chunkHolds       *sync.Map // chunkHolds contains the *chunkHold per requestID of a cursor whose buffer is full (with CursorBufferBlock)
+	inflight         *sync.Map // inflight contains the context and time of the last activity per requestID, used by the reaper to expire orphaned requests
+	expired          *sync.Map // expired contains the time of expiry per abandoned requestID, so that late responses can be discarded
+	responseSizes    *sync.Map // responseSizes contains the total size so far of the responses per requestID, for requests with a ResponseLimit
+	responseTimeout  time.Duration
+	responseLimit    ResponseLimit
+	bindingMode      BindingMode
+	lint             bool
+	queries          *QueryRegistry
+	cancelQuery      CancelQueryFunc
+	cursorBuffer     cursorBuffer
+	quit             chan struct{}
+	closeOnce        sync.Once
 	sync.Mutex
 	Errored bool
 }
@@ -67,19 +88,28 @@ func newClient() (c *Client) {
 		results:          &sync.Map{},
 		responseNotifier: &sync.Map{},
 		chunkNotifier:    &sync.Map{},
+		chunkHolds:       &sync.Map{},
+		inflight:         &sync.Map{},
+		expired:          &sync.Map{},
+		responseSizes:    &sync.Map{},
+		cursorBuffer:     cursorBuffer{size: defaultCursorBufferSize, holdLimit: defaultCursorHoldLimit},
+		quit:             make(chan struct{}),
 		Mutex:            sync.Mutex{},
 	}
 }
 
 // Dial returns a gremgo client for interaction with the Gremlin Server specified in the host IP.
-func Dial(conn dialer, errs chan error) (c *Client, err error) {
-	return DialCtx(context.Background(), conn, errs)
+func Dial(conn dialer, errs chan error, configs ...ClientConfig) (c *Client, err error) {
+	return DialCtx(context.Background(), conn, errs, configs...)
 }
 
 // DialCtx returns a gremgo client for interaction with the Gremlin Server specified in the host IP.
-func DialCtx(ctx context.Context, conn dialer, errs chan error) (c *Client, err error) {
+func DialCtx(ctx context.Context, conn dialer, errs chan error, configs ...ClientConfig) (c *Client, err error) {
 	c = newClient()
 	c.conn = conn
+	for _, conf := range configs {
+		conf(c)
+	}
 
 	// Connects to Gremlin Server
 	err = conn.connectCtx(ctx)
@@ -93,17 +123,18 @@ func DialCtx(ctx context.Context, conn dialer, errs chan error) (c *Client, err
 	go c.readWorkerCtx(ctx, msgChan, errs)
 	go c.saveWorkerCtx(ctx, msgChan, errs)
 	go conn.pingCtx(ctx, errs)
+	go c.reapWorkerCtx(ctx)
 
 	return
 }
 
-func (c *Client) executeRequest(query string, bindings, rebindings map[string]string) (resp []Response, err error) {
+func (c *Client) executeRequest(query string, bindings Bindings, rebindings map[string]string) (resp []Response, err error) {
 	return c.executeRequestCtx(context.Background(), query, bindings, rebindings)
 }
-func (c *Client) executeRequestCtx(ctx context.Context, query string, bindings, rebindings map[string]string) (resp []Response, err error) {
+func (c *Client) executeRequestCtx(ctx context.Context, query string, bindings Bindings, rebindings map[string]string) (resp []Response, err error) {
 	var req request
 	var id string
-	req, id, err = prepareRequest(query, bindings, rebindings)
+	req, id, err = c.prepareQuery(query, bindings, rebindings)
 	if err != nil {
 		return
 	}
@@ -114,17 +145,23 @@ func (c *Client) executeRequestCtx(ctx context.Context, query string, bindings,
 		return
 	}
 	c.responseNotifier.Store(id, make(chan error, 1))
-	c.dispatchRequestCtx(ctx, msg)
+	c.trackRequest(ctx, id)
+	c.trackResponseSize(ctx, id)
+	if err = c.dispatchRequestCtx(ctx, msg); err != nil {
+		c.expireRequest(id)
+		err = errors.Wrapf(err, "query: %s", query)
+		return
+	}
 	resp, err = c.retrieveResponseCtx(ctx, id)
 	if err != nil {
 		err = errors.Wrapf(err, "query: %s", query)
 	}
 	return
 }
-func (c *Client) executeRequestCursorCtx(ctx context.Context, query string, bindings, rebindings map[string]string) (cursor *Cursor, err error) {
+func (c *Client) executeRequestCursorCtx(ctx context.Context, query string, bindings Bindings, rebindings map[string]string) (cursor *Cursor, err error) {
 	var req request
 	var id string
-	if req, id, err = prepareRequest(query, bindings, rebindings); err != nil {
+	if req, id, err = c.prepareQuery(query, bindings, rebindings); err != nil {
 		return
 	}
 
@@ -134,20 +171,27 @@ func (c *Client) executeRequestCursorCtx(ctx context.Context, query string, bind
 		return
 	}
 	c.responseNotifier.Store(id, make(chan error, 1))
-	c.chunkNotifier.Store(id, make(chan bool, 10))
-	if c.dispatchRequestCtx(ctx, msg); err != nil {
+	c.chunkNotifier.Store(id, make(chan bool, c.cursorBuffer.size))
+	c.trackRequest(ctx, id)
+	c.trackResponseSize(ctx, id)
+	if err = c.dispatchRequestCtx(ctx, msg); err != nil {
+		c.expireRequest(id)
 		err = errors.Wrap(err, "executeRequestCursorCtx")
 		return
 	}
 
 	cursor = &Cursor{
-		ID: id,
+		ID:     id,
+		client: c,
 	}
 	return
 }
 
 func (c *Client) authenticate(requestID string) (err error) {
-	auth := c.conn.getAuth()
+	auth, err := c.conn.getAuth()
+	if err != nil {
+		return
+	}
 	req, err := prepareAuthRequest(requestID, auth.username, auth.password)
 	if err != nil {
 		return
@@ -171,10 +215,11 @@ func (c *Client) ExecuteCtx(ctx context.Context, query string, bindings, rebindi
 	if c.conn.IsDisposed() {
 		return resp, ErrorConnectionDisposed
 	}
-	return c.executeRequestCtx(ctx, query, bindings, rebindings)
+	return c.executeRequestCtx(ctx, query, stringBindings(bindings), rebindings)
 }
 
 // ExecuteFile takes a file path to a Gremlin script, sends it to Gremlin Server, and returns the result.
+// For scripts of several statements, see ExecuteScriptFileCtx.
 func (c *Client) ExecuteFile(path string, bindings, rebindings map[string]string) (resp []Response, err error) {
 	if c.conn.IsDisposed() {
 		return resp, ErrorConnectionDisposed
@@ -185,7 +230,7 @@ func (c *Client) ExecuteFile(path string, bindings, rebindings map[string]string
 		return
 	}
 	query := string(d)
-	return c.executeRequest(query, bindings, rebindings)
+	return c.executeRequest(query, stringBindings(bindings), rebindings)
 }
 
 // Get formats a raw Gremlin query, sends it to Gremlin Server, and populates the passed []interface.
@@ -201,7 +246,7 @@ func (c *Client) GetCtx(ctx context.Context, query string, bindings, rebindings
 	}
 
 	var resp []Response
-	resp, err = c.executeRequestCtx(ctx, query, bindings, rebindings)
+	resp, err = c.executeRequestCtx(ctx, query, stringBindings(bindings), rebindings)
 	if err != nil {
 		return
 	}
@@ -214,9 +259,9 @@ func (c *Client) deserializeResponseToVertices(resp []Response) (res []graphson.
 	}
 
 	for _, item := range resp {
-		resN, err := graphson.DeserializeListOfVerticesFromBytes(item.Result.Data)
-		if err != nil {
-			panic(err)
+		var resN []graphson.Vertex
+		if resN, err = graphson.DeserializeListOfVerticesFromBytes(item.Result.Data); err != nil {
+			return nil, err
 		}
 		res = append(res, resN...)
 	}
@@ -224,26 +269,43 @@ func (c *Client) deserializeResponseToVertices(resp []Response) (res []graphson.
 }
 
 // OpenStreamCursor initiates a query on the database, returning a stream cursor used to iterate over the results as they arrive.
-// The provided query must only return a string list, as the Read() function on Stream explicitly handles string values.
+// The provided query must only return a string list, as Stream explicitly handles string values.
+// The stream retrieves further responses with ctx (see Stream), and once ctx is done, the stream's request may be
+// abandoned (as by Close), so ctx must last until the stream is read.
 func (c *Client) OpenStreamCursor(ctx context.Context, query string, bindings, rebindings map[string]string) (*Stream, error) {
 	if c.conn.IsDisposed() {
 		return nil, ErrorConnectionDisposed
 	}
-	basicCursor, err := c.executeRequestCursorCtx(ctx, query, bindings, rebindings)
+	basicCursor, err := c.executeRequestCursorCtx(ctx, query, stringBindings(bindings), rebindings)
 	return &Stream{
 		cursor: basicCursor,
+		ctx:    ctx,
 		client: c,
 	}, err
 }
 
 // OpenCursorCtx initiates a query on the database, returning a cursor used to iterate over the results as they arrive.
 // The provided query must return a vertex or list of vertices in order for ReadCursorCtx to correctly format the results.
+// Once ctx is done, the cursor's request may be abandoned, so ctx must last until the cursor is read.
 func (c *Client) OpenCursorCtx(ctx context.Context, query string, bindings, rebindings map[string]string) (cursor *Cursor, err error) {
 	if c.conn.IsDisposed() {
 		err = ErrorConnectionDisposed
 		return
 	}
-	return c.executeRequestCursorCtx(ctx, query, bindings, rebindings)
+	return c.executeRequestCursorCtx(ctx, query, stringBindings(bindings), rebindings)
+}
+
+// OpenValueCursorCtx initiates a query on the database, returning a ValueCursor used to iterate over the
+// results (of any type) as they arrive
+func (c *Client) OpenValueCursorCtx(ctx context.Context, query string, bindings, rebindings map[string]string) (*ValueCursor, error) {
+	cursor, err := c.OpenCursorCtx(ctx, query, bindings, rebindings)
+	if err != nil {
+		return nil, err
+	}
+	return &ValueCursor{
+		Cursor: *cursor,
+		client: c,
+	}, nil
 }
 
 // ReadCursorCtx returns the next set of results, deserialized as []Vertex, for the cursor
@@ -263,6 +325,82 @@ func (c *Client) ReadCursorCtx(ctx context.Context, cursor *Cursor) (res []graph
 	return
 }
 
+// GetIntoCtx executes a query which returns vertices, and decodes them into dest
+// (a pointer to a slice of `graph`-tagged structs, see DecodeVertices)
+func (c *Client) GetIntoCtx(ctx context.Context, query string, bindings, rebindings map[string]string, dest interface{}) (err error) {
+	var res []graphson.Vertex
+	if res, err = c.GetCtx(ctx, query, bindings, rebindings); err != nil {
+		return
+	}
+	return DecodeVertices(res, dest)
+}
+
+// ReadCursorIntoCtx decodes the next set of results for the cursor into dest (see ReadCursorCtx and DecodeVertices)
+// - `dest` may be set to an empty slice when results were read by a previous call
+// - `eof` will be true when no more results are available
+func (c *Client) ReadCursorIntoCtx(ctx context.Context, cursor *Cursor, dest interface{}) (eof bool, err error) {
+	var res []graphson.Vertex
+	if res, eof, err = c.ReadCursorCtx(ctx, cursor); err != nil {
+		return
+	}
+	err = errors.Wrapf(DecodeVertices(res, dest), "ReadCursorIntoCtx: %s", cursor.ID)
+	return
+}
+
+// QueryCtx executes a query, returning its results decoded as a List of values (see DecodeGraphSON),
+// for queries returning e.g. maps (valueMap(), elementMap(), project(), group()), paths or trees
+func (c *Client) QueryCtx(ctx context.Context, query string, bindings, rebindings map[string]string) (res List, err error) {
+	var resp []Response
+	if resp, err = c.ExecuteCtx(ctx, query, bindings, rebindings); err != nil {
+		return
+	}
+	return DecodeResponses(resp)
+}
+
+// ChunkConsumer receives the decoded results of each response (chunk) of a query, as it arrives
+type ChunkConsumer func(chunk List) error
+
+// QueryChunksCtx executes a query, decoding each response as it arrives (large results are sent in chunks)
+// and passing its results to consume. Unlike QueryCtx, only the chunks not yet consumed are held in memory.
+// If consume returns an error, the rest of the results are discarded and that error is returned.
+// Responses are buffered as for a cursor, so a consume which cannot keep up may fail with ErrorCursorBufferFull
+// (see SetCursorBufferSize, SetCursorHoldLimit and SetCursorBufferPolicy).
+func (c *Client) QueryChunksCtx(ctx context.Context, query string, bindings, rebindings map[string]string, consume ChunkConsumer) (err error) {
+	if c.conn.IsDisposed() {
+		return ErrorConnectionDisposed
+	}
+	var cursor *Cursor
+	if cursor, err = c.executeRequestCursorCtx(ctx, query, stringBindings(bindings), rebindings); err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			c.expireRequest(cursor.ID)
+		}
+	}()
+
+	for done := false; !done; {
+		var resp []Response
+		if resp, done, err = c.retrieveNextResponseCtx(ctx, cursor); err != nil {
+			return
+		}
+		for i := range resp {
+			var chunk List
+			if chunk, err = DecodeResponses(resp[i : i+1]); err != nil {
+				return
+			}
+			resp[i] = Response{} // release the raw chunk before consuming its results
+			if len(chunk) == 0 {
+				continue
+			}
+			if err = consume(chunk); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
 // GetE formats a raw Gremlin query, sends it to Gremlin Server, and populates the passed []interface.
 func (c *Client) GetE(query string, bindings, rebindings map[string]string) (res []graphson.Edge, err error) {
 	return c.GetEdgeCtx(context.Background(), query, bindings, rebindings)
@@ -273,10 +411,14 @@ func (c *Client) GetEdgeCtx(ctx context.Context, query string, bindings, rebindi
 		return
 	}
 
-	resp, err := c.executeRequestCtx(ctx, query, bindings, rebindings)
+	resp, err := c.executeRequestCtx(ctx, query, stringBindings(bindings), rebindings)
 	if err != nil {
 		return
 	}
+	return c.deserializeResponseToEdges(resp)
+}
+
+func (c *Client) deserializeResponseToEdges(resp []Response) (res []graphson.Edge, err error) {
 	if len(resp) == 0 || resp[0].Status.Code == StatusNoContent {
 		return
 	}
@@ -300,6 +442,10 @@ func (c *Client) GetCountCtx(ctx context.Context, query string, bindings, rebind
 	if res, err = c.ExecuteCtx(ctx, query, bindings, rebindings); err != nil {
 		return
 	}
+	return c.deserializeResponseToCount(res)
+}
+
+func (c *Client) deserializeResponseToCount(res []Response) (i int64, err error) {
 	if len(res) > 1 {
 		err = errors.New("GetCount: expected one result, got more than one")
 		return
@@ -307,8 +453,15 @@ func (c *Client) GetCountCtx(ctx context.Context, query string, bindings, rebind
 		err = errors.New("GetCount: expected one result, got zero")
 		return
 	}
-	if i, err = graphson.DeserializeNumber(res[0].Result.Data); err != nil {
+	var val interface{}
+	if val, err = DecodeGraphSON(res[0].Result.Data); err != nil {
+		return
+	}
+	if list, ok := val.(List); !ok || len(list) != 1 {
+		err = fmt.Errorf("GetCount: expected a list of one number, but got %v", val)
 		return
+	} else if i, err = ToInt64(list[0]); err != nil {
+		err = errors.Wrap(err, "GetCount")
 	}
 	return
 }
@@ -322,6 +475,10 @@ func (c *Client) GetStringListCtx(ctx context.Context, query string, bindings, r
 	if res, err = c.ExecuteCtx(ctx, query, bindings, rebindings); err != nil {
 		return
 	}
+	return c.deserializeResponseToStringList(res)
+}
+
+func (c *Client) deserializeResponseToStringList(res []Response) (vals []string, err error) {
 	for _, resN := range res {
 		var valsN []string
 		if valsN, err = graphson.DeserializeStringListFromBytes(resN.Result.Data); err != nil {
@@ -341,89 +498,89 @@ func (c *Client) GetPropertiesCtx(ctx context.Context, query string, bindings, r
 	if res, err = c.ExecuteCtx(ctx, query, bindings, rebindings); err != nil {
 		return
 	}
+	return c.deserializeResponseToProperties(res)
+}
+
+func (c *Client) deserializeResponseToProperties(res []Response) (vals map[string][]interface{}, err error) {
 	vals = make(map[string][]interface{})
 	for _, resN := range res {
 		if err = graphson.DeserializePropertiesFromBytes(resN.Result.Data, vals); err != nil {
 			return
 		}
 	}
+	for key, propVals := range vals {
+		for i := range propVals {
+			if propVals[i], err = decodeTypedInterface(propVals[i]); err != nil {
+				return nil, errors.Wrapf(err, "property %q", key)
+			}
+		}
+	}
 	return
 }
 
 // GremlinForVertex returns the addV()... and V()... gremlin commands for `data`
 // Because of possible multiples, it does not start with `g.` (it probably should? XXX )
+// Properties tagged with the option single, list or set are added with that cardinality, e.g. `graph:"title,string,single"`
+//
+// A tag without a type option (e.g. `graph:"title"`) infers it from the field, which may be a string, bool, number,
+// time.Time (as a datetime), TextMarshaler (e.g. uuid.UUID, as its text), json.RawMessage (as a string), a slice of
+// these (a property per item), or a map[string]T of these (flattened into a property per key, e.g. "attrs.colour").
+// Fields which are nil pointers are omitted, and the tagged fields of embedded structs are included.
 // (largely taken from https://github.com/intwinelabs/gremgoser)
 func GremlinForVertex(label string, data interface{}) (gremAdd, gremGet string, err error) {
-	gremAdd = fmt.Sprintf("addV('%s')", label)
-	gremGet = fmt.Sprintf("V('%s')", label)
-
-	d := reflect.ValueOf(data)
-	id := d.FieldByName("Id")
-	if id.IsValid() {
-		if idField, ok := d.Type().FieldByName("Id"); ok {
-			tag := idField.Tag.Get("graph")
-			name, opts := parseTag(tag)
-			if len(name) == 0 && len(opts) == 0 {
-				gremAdd += fmt.Sprintf(".property(id,'%s')", id)
-				gremGet += fmt.Sprintf(".hasId('%s')", id)
-			}
-		}
+	var lbl string
+	if lbl, err = traversal.Literal(label); err != nil {
+		return
 	}
+	gremAdd = "addV(" + lbl + ")"
+	gremGet = "V(" + lbl + ")"
 
-	missingTag := true
+	var sv structElement
+	if sv, err = readStruct(data); err != nil && err != ErrorNoGraphTags {
+		return
+	}
+	// ErrorNoGraphTags is effectively a warning for gremGet (can be ignored, unless no Id)
+	tagsErr := err
 
-	for i := 0; i < d.NumField(); i++ {
-		tag := d.Type().Field(i).Tag.Get("graph")
-		name, opts := parseTag(tag)
-		if (len(name) == 0 || name == "-") && len(opts) == 0 {
-			continue
-		}
-		missingTag = false
-		val := d.Field(i).Interface()
-		if len(opts) == 0 {
-			err = fmt.Errorf("interface field tag %q does not contain a tag option type, field type: %T", name, val)
+	if sv.id != nil {
+		var lit string
+		if lit, err = traversal.Literal(sv.id); err != nil {
 			return
 		}
-		if !d.Field(i).IsValid() {
-			continue
-		}
-		if opts.Contains("id") {
-			if val != "" {
-				gremAdd += fmt.Sprintf(".property(id,'%s')", val)
-				gremGet += fmt.Sprintf(".hasId('%s')", val)
-			}
-		} else if opts.Contains("string") {
-			if val != "" {
-				gremAdd += fmt.Sprintf(".property('%s','%s')", name, escapeStringy(val.(string)))
-				gremGet += fmt.Sprintf(".has('%s','%s')", name, escapeStringy(val))
-			}
-		} else if opts.Contains("bool") || opts.Contains("number") || opts.Contains("other") {
-			gremAdd += fmt.Sprintf(".property('%s',%v)", name, val)
-			gremGet += fmt.Sprintf(".has('%s',%v)", name, val)
-		} else if opts.Contains("[]string") {
-			s := reflect.ValueOf(val)
-			for i := 0; i < s.Len(); i++ {
-				gremAdd += fmt.Sprintf(".property('%s','%s')", name, escapeStringy(s.Index(i).Interface()))
-				gremGet += fmt.Sprintf(".has('%s','%s')", name, escapeStringy(s.Index(i).Interface()))
-			}
-		} else if opts.Contains("[]bool") || opts.Contains("[]number") || opts.Contains("[]other") {
-			s := reflect.ValueOf(val)
-			for i := 0; i < s.Len(); i++ {
-				gremAdd += fmt.Sprintf(".property('%s',%v)", name, s.Index(i).Interface())
-				gremGet += fmt.Sprintf(".has('%s',%v)", name, s.Index(i).Interface())
+		gremAdd += ".property(id," + lit + ")"
+		gremGet += ".hasId(" + lit + ")"
+	}
+	for _, prop := range sv.props {
+		for _, val := range prop.values {
+			if err = addPropertySteps(&gremAdd, &gremGet, prop, val); err != nil {
+				return
 			}
-		} else {
-			err = fmt.Errorf("interface field tag needs recognised option, field: %q, tag: %q", d.Type().Field(i).Name, tag)
-			return
 		}
 	}
+	err = tagsErr
+	return
+}
 
-	if missingTag {
-		// this err is effectively a warning for gremGet (can be ignored, unless no Id)
-		err = ErrorNoGraphTags
-		return
+// addPropertySteps appends the `.property([cardinality,]name,val)` and `.has(name,val)` steps for prop to gremAdd
+// and gremGet, with name and val as Gremlin-Groovy literals
+func addPropertySteps(gremAdd, gremGet *string, prop structProperty, val interface{}) error {
+	key, err := traversal.Literal(prop.name)
+	if err != nil {
+		return err
 	}
-	return
+	lit, err := traversal.Literal(val)
+	if err != nil {
+		return errors.Wrapf(ErrorUnsupportedPropertyType, "property %q: %v", prop.name, err)
+	}
+	args := key + "," + lit
+	if tok, ok := prop.cardinalityToken(); ok {
+		card, _ := traversal.Literal(tok)
+		*gremAdd += ".property(" + card + "," + args + ")"
+	} else {
+		*gremAdd += ".property(" + args + ")"
+	}
+	*gremGet += ".has(" + args + ")"
+	return nil
 }
 
 // AddV takes a label and an interface and adds it as a vertex to the graph
@@ -437,7 +594,7 @@ func (c *Client) AddVertexCtx(ctx context.Context, label string, data interface{
 
 	q, _, err := GremlinForVertex(label, data)
 	if err != nil && err != ErrorNoGraphTags {
-		panic(err) // XXX
+		return
 	}
 	q = "g." + q
 
@@ -477,49 +634,65 @@ func (c *Client) AddEdgeCtx(ctx context.Context, label, fromId, toId string, pro
 	if propStr, err = buildProps(props); err != nil {
 		return
 	}
-	q := fmt.Sprintf("g.addE('%s').from(g.V().hasId('%s')).to(g.V().hasId('%s'))%s", label, fromId, toId, propStr)
+	var lbl, from, to string
+	if lbl, err = traversal.Literal(label); err != nil {
+		return
+	}
+	if from, err = traversal.Literal(fromId); err != nil {
+		return
+	}
+	if to, err = traversal.Literal(toId); err != nil {
+		return
+	}
+	q := "g.addE(" + lbl + ").from(g.V().hasId(" + from + ")).to(g.V().hasId(" + to + "))" + propStr
 	resp, err = c.ExecuteCtx(ctx, q, nil, nil)
 	return
 }
 
 // Close closes the underlying connection and marks the client as closed.
 func (c *Client) Close() {
+	c.closeOnce.Do(func() {
+		if c.quit != nil {
+			close(c.quit)
+		}
+	})
 	if c.conn != nil {
 		c.conn.close()
 	}
 }
 
-// buildProps converts a map[string]interfaces to be used as properties on an edge
+// buildProps converts a map[string]interfaces to be used as properties on an edge, in key order, with the values
+// as for a struct field tagged without a type option (see GremlinForVertex), e.g. a slice being a property per item
 // (largely taken from https://github.com/intwinelabs/gremgoser)
 func buildProps(props map[string]interface{}) (q string, err error) {
-	for k, v := range props {
-		t := reflect.ValueOf(v).Kind()
-		if t == reflect.String {
-			q += fmt.Sprintf(".property('%s', '%s')", k, escapeStringy(v))
-		} else if t == reflect.Bool || t == reflect.Int || t == reflect.Int8 || t == reflect.Int16 || t == reflect.Int32 || t == reflect.Int64 || t == reflect.Uint || t == reflect.Uint8 || t == reflect.Uint16 || t == reflect.Uint32 || t == reflect.Uint64 || t == reflect.Float32 || t == reflect.Float64 {
-			q += fmt.Sprintf(".property('%s', %v)", k, v)
-		} else if t == reflect.Slice {
-			s := reflect.ValueOf(v)
-			for i := 0; i < s.Len(); i++ {
-				q += fmt.Sprintf(".property('%s', '%s')", k, escapeStringy(s.Index(i).Interface()))
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sps []structProperty
+	for _, k := range keys {
+		if sps, err = appendProperties(sps, structProperty{name: k}, reflect.ValueOf(props[k])); err != nil {
+			return "", err
+		}
+	}
+	for _, prop := range sps {
+		var key string
+		if key, err = traversal.Literal(prop.name); err != nil {
+			return "", err
+		}
+		for _, val := range prop.values {
+			var lit string
+			if lit, err = traversal.Literal(val); err != nil {
+				return "", errors.Wrapf(ErrorUnsupportedPropertyType, "property %q: %v", prop.name, err)
 			}
-		} else {
-			return "", ErrorUnsupportedPropertyType
+			q += ".property(" + key + ", " + lit + ")"
 		}
 	}
 	return
 }
 
-// escapeStringy takes a string and escapes some characters
-// (largely taken from https://github.com/intwinelabs/gremgoser)
-func escapeStringy(stringy interface{}) string {
-	var buf bytes.Buffer
-	for _, char := range stringy.(string) {
-		switch char {
-		case '\'', '"', '\\':
-			buf.WriteRune('\\')
-		}
-		buf.WriteRune(char)
-	}
-	return buf.String()
+// isList returns true if v is a slice or array
+func isList(v reflect.Value) bool {
+	return v.Kind() == reflect.Slice || v.Kind() == reflect.Array
 }
@@ -1,8 +1,17 @@
 package gremgo
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -37,6 +46,16 @@ func TestGremlin(t *testing.T) {
 		PropBool  bool     `graph:"prop,bool"`
 		PropArray []string `graph:"ps,[]string"`
 	}
+	type StructNumbers struct {
+		Count int64     `graph:"count,number"`
+		Ratio float64   `graph:"ratio,number"`
+		Sizes []float32 `graph:"sizes,[]number"`
+	}
+	type StructCardinality struct {
+		Title string   `graph:"title,string,single"`
+		Tags  []string `graph:"tags,[]string,set"`
+		Notes []string `graph:"notes,[]string,list"`
+	}
 
 	res := []testGremlin{
 		{
@@ -80,6 +99,27 @@ func TestGremlin(t *testing.T) {
 			expectAdd: `addV('typer').property('prop',true).property('ps','ook').property('ps','foo')`,
 			expectGet: `V('typer').has('prop',true).has('ps','ook').has('ps','foo')`,
 		},
+		{
+			title:     "injection escaped",
+			input:     StructSane{Id: `x\'`, Prop: "a')\n.drop();g.V('${evil}\u2028"},
+			label:     "esc'",
+			expectAdd: `addV('esc\'').property(id,'x\\\'').property('prop','a\')\n.drop();g.V(\'${evil}\u2028')`,
+			expectGet: `V('esc\'').hasId('x\\\'').has('prop','a\')\n.drop();g.V(\'${evil}\u2028')`,
+		},
+		{
+			title:     "check numbers",
+			input:     StructNumbers{Count: 3, Ratio: 0.5, Sizes: []float32{1, 2.5}},
+			label:     "numbers",
+			expectAdd: `addV('numbers').property('count',3).property('ratio',0.5d).property('sizes',1f).property('sizes',2.5f)`,
+			expectGet: `V('numbers').has('count',3).has('ratio',0.5d).has('sizes',1f).has('sizes',2.5f)`,
+		},
+		{
+			title:     "check cardinality",
+			input:     StructCardinality{Title: "t", Tags: []string{"a", "b"}, Notes: []string{"n"}},
+			label:     "card",
+			expectAdd: `addV('card').property(VertexProperty.Cardinality.single,'title','t').property(VertexProperty.Cardinality.set,'tags','a').property(VertexProperty.Cardinality.set,'tags','b').property(VertexProperty.Cardinality.list,'notes','n')`,
+			expectGet: `V('card').has('title','t').has('tags','a').has('tags','b').has('notes','n')`,
+		},
 		{
 			title:     "check non-Id ID",
 			input:     StructOtherId{Idee: "idee-id", Prop: "prop-val"},
@@ -110,3 +150,376 @@ func TestGremlin(t *testing.T) {
 		})
 	}
 }
+
+func TestGremlinForVertexTypes(t *testing.T) {
+	type Audit struct {
+		Created time.Time `graph:"created"`
+		Editor  *string   `graph:"editor"`
+	}
+	type StructInferred struct {
+		*Audit
+		Id     uuid.UUID
+		Title  string            `graph:"title,single"`
+		Count  *int              `graph:"count"`
+		Ratio  *float64          `graph:"ratio,number"`
+		Tags   []string          `graph:"tags,set"`
+		Attrs  map[string]string `graph:"attrs"`
+		Raw    json.RawMessage   `graph:"raw"`
+		Parent *uuid.UUID        `graph:"parent,other"`
+	}
+	id := uuid.Must(uuid.FromString("6ba7b810-9dad-11d1-80b4-00c04fd430c8"))
+	count := 2
+	editor := "ed"
+
+	testData := []struct {
+		input     interface{}
+		expectAdd string
+	}{
+		{
+			StructInferred{
+				Audit: &Audit{Created: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), Editor: &editor},
+				Id:    id, Title: "t", Count: &count, Tags: []string{"a", "b"},
+				Attrs: map[string]string{"size": "L", "colour": "red"}, Raw: json.RawMessage(`[1]`), Parent: &id,
+			},
+			`addV('l').property(id,'6ba7b810-9dad-11d1-80b4-00c04fd430c8').property('created',datetime('2020-01-02T03:04:05Z'))` +
+				`.property('editor','ed').property(VertexProperty.Cardinality.single,'title','t').property('count',2)` +
+				`.property(VertexProperty.Cardinality.set,'tags','a').property(VertexProperty.Cardinality.set,'tags','b')` +
+				`.property('attrs.colour','red').property('attrs.size','L').property('raw','[1]')` +
+				`.property('parent','6ba7b810-9dad-11d1-80b4-00c04fd430c8')`,
+		},
+		{
+			&StructInferred{Title: "nil pointers omitted"},
+			`addV('l').property(VertexProperty.Cardinality.single,'title','nil pointers omitted')`,
+		},
+	}
+	for _, td := range testData {
+		add, _, err := GremlinForVertex("l", td.input)
+		if err != nil || add != td.expectAdd {
+			t.Errorf("Expected\n%s\ngot\n%s %v", td.expectAdd, add, err)
+		}
+	}
+
+	type StructUnsupported struct {
+		Nested struct{ A int } `graph:"nested"`
+	}
+	type StructTypo struct {
+		Title string `graph:"title,strnig"`
+	}
+	type StructMapKey struct {
+		Attrs map[int]string `graph:"attrs"`
+	}
+	for _, input := range []interface{}{StructUnsupported{}, StructTypo{}, StructMapKey{Attrs: map[int]string{1: "a"}}} {
+		if _, _, err := GremlinForVertex("l", input); err == nil {
+			t.Errorf("Expected an error for %T", input)
+		}
+	}
+}
+
+func TestBuildProps(t *testing.T) {
+	testData := []struct {
+		props    map[string]interface{}
+		expected string
+	}{
+		{map[string]interface{}{"name": "it's\n${x}"}, `.property('name', 'it\'s\n${x}')`},
+		{map[string]interface{}{"n": 2}, `.property('n', 2)`},
+		{map[string]interface{}{"tags": []string{"a", "b"}}, `.property('tags', 'a').property('tags', 'b')`},
+		{map[string]interface{}{"ns": []int64{1}}, `.property('ns', 1)`},
+		{map[string]interface{}{"b": 1, "a": 2}, `.property('a', 2).property('b', 1)`},
+		{map[string]interface{}{"attrs": map[string]interface{}{"y": "b", "x": []string{"a"}}}, `.property('attrs.x', 'a').property('attrs.y', 'b')`},
+		{map[string]interface{}{"p": (*string)(nil)}, ``},
+		{map[string]interface{}{"at": time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)}, `.property('at', datetime('2020-01-02T03:04:05Z'))`},
+		{map[string]interface{}{"u": uuid.Must(uuid.FromString("6ba7b810-9dad-11d1-80b4-00c04fd430c8"))}, `.property('u', '6ba7b810-9dad-11d1-80b4-00c04fd430c8')`},
+		{map[string]interface{}{"j": json.RawMessage(`{"a":'1'}`)}, `.property('j', '{"a":\'1\'}')`},
+	}
+	for _, td := range testData {
+		q, err := buildProps(td.props)
+		if err != nil || q != td.expected {
+			t.Errorf("Expected %s, got %s %v", td.expected, q, err)
+		}
+	}
+
+	for _, props := range []map[string]interface{}{{"m": map[int]string{}}, {"s": struct{}{}}, {"l": [][]string{{"a"}}}, {"n": nil}, {"s": "\xff"}} {
+		if _, err := buildProps(props); errors.Cause(err) != ErrorUnsupportedPropertyType {
+			t.Errorf("Expected %v for %v, got %v", ErrorUnsupportedPropertyType, props, err)
+		}
+	}
+}
+
+// FuzzDeserializeResponse tests that no result data from the server can panic the deserializers
+func FuzzDeserializeResponse(f *testing.F) {
+	for _, seed := range []string{
+		`{"@type":"g:List","@value":[{"@type":"g:Vertex","@value":{"id":"test-id","label":"l","properties":{"p":[{"@type":"g:VertexProperty","@value":{"id":{"@type":"Type","@value":1},"value":"1212","label":"p"}}]}}}]}`,
+		`{"@type":"g:List","@value":[{"@type":"g:Edge","@value":{"id":"e","label":"l","inVLabel":"a","outVLabel":"b","inV":"1","outV":"2","properties":{"p":{"@type":"g:Property","@value":{"key":"p","value":"v"}}}}}]}`,
+		`{"@type":"g:List","@value":[{"@type":"g:Int64","@value":3}]}`,
+		`{"@type":"g:List","@value":[{"@type":"g:Int64","@value":"3"}]}`,
+		`{"@type":"g:List","@value":["a","b"]}`,
+		`{"@type":"g:List","@value":[{"@type":"g:Map","@value":["k",{"@type":"g:List","@value":["v"]}]}]}`,
+		`{"@type":"g:List","@value":null}`,
+		`{"@type":"g:Map","@value":[1]}`,
+		`null`,
+		``,
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		resp := []Response{{Status: Status{Code: StatusSuccess}, Result: Result{Data: data}}}
+		c := newClient()
+		c.deserializeResponseToVertices(resp)
+		c.deserializeResponseToEdges(resp)
+		c.deserializeResponseToCount(resp)
+		c.deserializeResponseToStringList(resp)
+		c.deserializeResponseToProperties(resp)
+
+		s := &Stream{
+			cursor: &Cursor{ID: "cursorId"},
+			client: &RetrieverMock{
+				retrieveNextResponseCtxFunc: func(ctx context.Context, cursor *Cursor) ([]Response, bool, error) {
+					return resp, true, nil
+				},
+			},
+		}
+		for i := 0; i < 3; i++ {
+			if _, err := s.ReadLine(); err != nil {
+				break
+			}
+		}
+	})
+}
+
+func TestGremlinForVertexErrors(t *testing.T) {
+	type StructWrongType struct {
+		Prop int `graph:"prop,string"`
+	}
+	type StructWrongSlice struct {
+		Props []int `graph:"props,[]string"`
+	}
+	type StructNotSlice struct {
+		Props bool `graph:"props,[]bool"`
+	}
+	type StructUnexported struct {
+		prop string `graph:"prop,string"`
+	}
+	type StructCardinalities struct {
+		Prop string `graph:"prop,string,single,set"`
+	}
+	type StructSingleSlice struct {
+		Props []string `graph:"props,[]string,single"`
+	}
+	var nilStruct *StructWrongType
+
+	for _, input := range []interface{}{
+		"not-a-struct",
+		nil,
+		nilStruct,
+		StructWrongType{Prop: 1},
+		StructWrongSlice{Props: []int{1}},
+		StructNotSlice{Props: true},
+		StructUnexported{prop: "p"},
+		StructCardinalities{Prop: "p"},
+		StructSingleSlice{Props: []string{"p"}},
+	} {
+		Convey(fmt.Sprintf("Test GremlinForVertex error for %T", input), t, func() {
+			So(func() { _, _, _ = GremlinForVertex("label", input) }, ShouldNotPanic)
+			_, _, err := GremlinForVertex("label", input)
+			So(err, ShouldNotBeNil)
+		})
+	}
+
+	Convey("Test GremlinForVertex with pointer to struct", t, func() {
+		type StructSane struct {
+			Prop string `graph:"prop,string"`
+		}
+		outAdd, _, err := GremlinForVertex("label", &StructSane{Prop: "p"})
+		So(err, ShouldBeNil)
+		So(outAdd, ShouldEqual, "addV('label').property('prop','p')")
+	})
+}
+
+// serveChunks fakes the server for the next request on c, responding with `chunks` partial responses
+// (each a g:List of `rows` strings) then an empty final response, or finalErr
+func serveChunks(tb testing.TB, c *Client, chunks, rows int, finalStatus Status, finalErr error) {
+	row := `"` + strings.Repeat("x", 64) + `"`
+	list := `{"@type":"g:List","@value":[` + strings.Repeat(row+",", rows-1) + row + `]}`
+	go func() {
+		msg := <-c.requests
+		var req request
+		if err := json.Unmarshal(msg[len(mimeTypePrefix):], &req); err != nil {
+			tb.Error(err)
+			return
+		}
+		for i := 0; i < chunks; i++ {
+			waitForCursorBuffer(c, req.RequestID)
+			// a buffer for each chunk (as read from the connection), so that keeping chunks shows in the memory used
+			data := json.RawMessage(list)
+			c.saveResponse(Response{RequestID: req.RequestID, Status: Status{Code: StatusPartialContent}, Result: Result{Data: data}}, nil)
+		}
+		c.saveResponse(Response{RequestID: req.RequestID, Status: finalStatus}, finalErr)
+	}()
+}
+
+// waitForCursorBuffer waits while the buffer of cursor id is full, as for a server which sends responses no faster
+// than they are read (otherwise a reader which falls behind by more than the hold limit fails)
+func waitForCursorBuffer(c *Client, id string) {
+	for {
+		notifier, ok := c.chunkNotifier.Load(id)
+		if !ok || len(notifier.(chan bool)) < cap(notifier.(chan bool)) {
+			return
+		}
+		runtime.Gosched()
+	}
+}
+
+func newChunksClient() *Client {
+	c := newClient()
+	c.conn = &dialerMock{IsDisposedFunc: func() bool { return false }}
+	return c
+}
+
+func TestQueryChunks(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Run("all chunks consumed in order", func(t *testing.T) {
+		c := newChunksClient()
+		serveChunks(t, c, 50, 10, Status{Code: StatusNoContent}, nil)
+		var chunks, rows int
+		err := c.QueryChunksCtx(ctx, "g.V().values('name')", nil, nil, func(chunk List) error {
+			chunks++
+			rows += len(chunk)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if chunks != 50 || rows != 500 {
+			t.Errorf("Expected 50 chunks of 500 rows, got %d chunks of %d rows", chunks, rows)
+		}
+		assertNoRequestState(t, c)
+	})
+
+	t.Run("consumer error discards the rest", func(t *testing.T) {
+		c := newChunksClient()
+		serveChunks(t, c, 50, 10, Status{Code: StatusNoContent}, nil)
+		stop := errors.New("stop")
+		var chunks int
+		err := c.QueryChunksCtx(ctx, "g.V().values('name')", nil, nil, func(chunk List) error {
+			if chunks++; chunks == 3 {
+				return stop
+			}
+			return nil
+		})
+		if err != stop {
+			t.Errorf("Expected %v, got %v", stop, err)
+		}
+		// remaining chunks are discarded, without blocking the server responses
+		time.Sleep(50 * time.Millisecond)
+		assertNoRequestState(t, c)
+	})
+
+	t.Run("server error after chunks", func(t *testing.T) {
+		c := newChunksClient()
+		serverErr := &ServerError{Code: StatusServerError, Message: "BOOM"}
+		serveChunks(t, c, 5, 10, Status{Code: StatusServerError, Message: "BOOM"}, serverErr)
+		var chunks int
+		err := c.QueryChunksCtx(ctx, "g.V().values('name')", nil, nil, func(chunk List) error {
+			chunks++
+			return nil
+		})
+		if !errors.Is(err, ErrServerError) {
+			t.Errorf("Expected %v, got %v", ErrServerError, err)
+		}
+		if chunks != 5 {
+			t.Errorf("Expected the 5 chunks before the error, got %d", chunks)
+		}
+	})
+}
+
+// peakHeap samples the heap in use until stop is called, which returns the peak (in MB)
+func peakHeap() (stop func() float64) {
+	var peak uint64
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		var ms runtime.MemStats
+		for {
+			runtime.ReadMemStats(&ms)
+			if ms.HeapInuse > atomic.LoadUint64(&peak) {
+				atomic.StoreUint64(&peak, ms.HeapInuse)
+			}
+			select {
+			case <-done:
+				return
+			case <-time.After(time.Millisecond):
+			}
+		}
+	}()
+	return func() float64 {
+		close(done)
+		<-finished
+		return float64(atomic.LoadUint64(&peak)) / (1 << 20)
+	}
+}
+
+// benchmark a ~35MB result (500 chunks of 1000 rows), to compare the peak heap of QueryChunksCtx with QueryCtx
+const benchChunks, benchRows = 500, 1000
+
+// queryChunksPeakHeap returns the peak heap (in MB) of consuming a result of chunks (of rows) with QueryChunksCtx
+func queryChunksPeakHeap(tb testing.TB, chunks, rows int) float64 {
+	runtime.GC()
+	stop := peakHeap()
+	c := newChunksClient()
+	serveChunks(tb, c, chunks, rows, Status{Code: StatusNoContent}, nil)
+	var total int
+	if err := c.QueryChunksCtx(context.Background(), "g.V().values('name')", nil, nil, func(chunk List) error {
+		total += len(chunk)
+		return nil
+	}); err != nil {
+		tb.Fatal(err)
+	}
+	if total != chunks*rows {
+		tb.Fatalf("Expected %d rows, got %d", chunks*rows, total)
+	}
+	return stop()
+}
+
+func TestQueryChunksMemory(t *testing.T) {
+	// 4 times the chunks (~1.7MB, then ~7MB of responses, more once decoded) must not need more memory
+	small := queryChunksPeakHeap(t, 250, 100)
+	large := queryChunksPeakHeap(t, 1000, 100)
+	if large > small+3 {
+		t.Errorf("Expected the peak heap not to grow with the number of chunks, got %.1fMB for 250, %.1fMB for 1000", small, large)
+	}
+}
+
+func BenchmarkQueryChunks(b *testing.B) {
+	var peak float64
+	for i := 0; i < b.N; i++ {
+		if p := queryChunksPeakHeap(b, benchChunks, benchRows); p > peak {
+			peak = p
+		}
+	}
+	b.ReportMetric(peak, "peak-heap-MB")
+}
+
+func BenchmarkQuery(b *testing.B) {
+	ctx := context.Background()
+	var peak float64
+	for i := 0; i < b.N; i++ {
+		runtime.GC()
+		stop := peakHeap()
+		c := newChunksClient()
+		serveChunks(b, c, benchChunks, benchRows, Status{Code: StatusNoContent}, nil)
+		res, err := c.QueryCtx(ctx, "g.V().values('name')", nil, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(res) != benchChunks*benchRows {
+			b.Fatalf("Expected %d rows, got %d", benchChunks*benchRows, len(res))
+		}
+		if p := stop(); p > peak {
+			peak = p
+		}
+	}
+	b.ReportMetric(peak, "peak-heap-MB")
+}
@@ -59,3 +59,90 @@ func SetRequestHeaders(requestHeaders http.Header) DialerConfig {
 		c.requestHeaders = requestHeaders
 	}
 }
+
+//ClientConfig is the struct for defining configuration for the gremgo Client
+type ClientConfig func(*Client)
+
+//SetResponseTimeout sets the default time to wait for a response to a request,
+//after which the request is abandoned and any late responses are discarded (zero disables the timeout)
+func SetResponseTimeout(timeout time.Duration) ClientConfig {
+	return func(c *Client) {
+		c.responseTimeout = timeout
+	}
+}
+
+//SetCursorBufferSize sets the number of unread partial responses (chunks) buffered per cursor,
+//before the cursor buffer policy applies (default 10)
+func SetCursorBufferSize(size int) ClientConfig {
+	return func(c *Client) {
+		if size > 0 {
+			c.cursorBuffer.size = size
+		}
+	}
+}
+
+//SetCursorBufferPolicy sets what happens when a cursor's buffer is full (default CursorBufferBlock)
+func SetCursorBufferPolicy(policy CursorBufferPolicy) ClientConfig {
+	return func(c *Client) {
+		c.cursorBuffer.policy = policy
+	}
+}
+
+//SetCursorHoldLimit sets the number of partial responses held for a cursor beyond its full buffer (with
+//CursorBufferBlock), until it is read, after which its request fails with ErrorCursorBufferFull (default 100).
+//So at most the buffer size plus the hold limit of unread responses are kept for each cursor.
+func SetCursorHoldLimit(limit int) ClientConfig {
+	return func(c *Client) {
+		if limit >= 0 {
+			c.cursorBuffer.holdLimit = limit
+		}
+	}
+}
+
+//SetCursorBlockTimeout sets the maximum time to wait for a cursor to be read when its buffer is full
+//(with CursorBufferBlock), after which its request fails with ErrorCursorBufferFull (by default, zero waits
+//indefinitely). The partial responses which arrive meanwhile are held, up to the hold limit (see SetCursorHoldLimit).
+func SetCursorBlockTimeout(timeout time.Duration) ClientConfig {
+	return func(c *Client) {
+		c.cursorBuffer.blockTimeout = timeout
+	}
+}
+
+//SetResponseLimit sets the default cap on the total size of the responses to each request, after which
+//the request fails with a *ResponseTooLargeError (see WithResponseLimit, to set the limit for a request)
+func SetResponseLimit(limit ResponseLimit) ClientConfig {
+	return func(c *Client) {
+		c.responseLimit = limit
+	}
+}
+
+//SetCancelQuery sets the function used to cancel a query on the server when its responses exceed
+//the response limit (e.g. NeptuneCancelQuery)
+func SetCancelQuery(cancelQuery CancelQueryFunc) ClientConfig {
+	return func(c *Client) {
+		c.cancelQuery = cancelQuery
+	}
+}
+
+//SetBindingMode sets whether the bindings of queries are sent to the server (BindingModeServer, the default)
+//or substituted into the queries client-side (BindingModeInterpolate, for Neptune)
+func SetBindingMode(mode BindingMode) ClientConfig {
+	return func(c *Client) {
+		c.bindingMode = mode
+	}
+}
+
+//SetLint sets whether each query is checked by the lint package before it is sent, failing with a *LintError
+//(without sending anything) if it uses any features which Neptune does not support
+func SetLint(enabled bool) ClientConfig {
+	return func(c *Client) {
+		c.lint = enabled
+	}
+}
+
+//SetQueryRegistry sets the named queries run by Named (see LoadQueryRegistry)
+func SetQueryRegistry(queries *QueryRegistry) ClientConfig {
+	return func(c *Client) {
+		c.queries = queries
+	}
+}
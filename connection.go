@@ -21,7 +21,7 @@ type dialer interface {
 	read() (int, []byte, error)
 	readCtx(context.Context, chan message)
 	close() error
-	getAuth() *auth
+	getAuth() (*auth, error)
 	ping(errs chan error)
 	pingCtx(context.Context, chan error)
 }
@@ -132,11 +132,11 @@ func (ws *Ws) close() (err error) {
 	return
 }
 
-func (ws *Ws) getAuth() *auth {
+func (ws *Ws) getAuth() (*auth, error) {
 	if ws.auth == nil {
-		panic("You must create a Secure Dialer for authenticating with the server")
+		return nil, ErrorNoAuthCredentials
 	}
-	return ws.auth
+	return ws.auth, nil
 }
 
 func (ws *Ws) ping(errs chan error) {
@@ -2,16 +2,16 @@ package gremgo
 
 import "testing"
 
-func TestPanicOnMissingAuthCredentials(t *testing.T) {
+func TestErrorOnMissingAuthCredentials(t *testing.T) {
 	c := newClient()
 	ws := new(Ws)
 	c.conn = ws
 
-	defer func() {
-		if r := recover(); r == nil {
-			t.Fail()
-		}
-	}()
+	if _, err := c.conn.getAuth(); err != ErrorNoAuthCredentials {
+		t.Errorf("Expected %v, got %v", ErrorNoAuthCredentials, err)
+	}
 
-	c.conn.getAuth()
+	if err := c.handleResponse(dummyNeedAuthenticationResponse); err != ErrorNoAuthCredentials {
+		t.Errorf("Expected handleResponse to return %v, got %v", ErrorNoAuthCredentials, err)
+	}
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/ONSdigital/graphson"
 	"github.com/pkg/errors"
@@ -13,34 +14,262 @@ import (
 
 type Retriever interface {
 	retrieveNextResponseCtx(ctx context.Context, cursor *Cursor) (data []Response, done bool, err error)
+	closeCursor(cursor *Cursor)
 }
 
 // Cursor allows for results to be iterated over as soon as available, rather than waiting for
 // a query to complete and all results to be returned in one block.
 type Cursor struct {
 	ID string
+	// client is the client which opened the cursor, the only one which receives its results
+	client *Client
+}
+
+const (
+	defaultCursorBufferSize = 10
+	defaultCursorHoldLimit  = 100
+)
+
+// CursorBufferPolicy determines what happens when a partial response arrives for a cursor
+// whose buffer of unread responses is full
+type CursorBufferPolicy int
+
+const (
+	// CursorBufferBlock holds further partial responses for the cursor until it is read, up to the hold limit and
+	// for up to any block timeout, then fails its request. Responses to other requests on the connection are not
+	// held up, so the responses held meanwhile are bounded by the hold limit (see SetCursorHoldLimit).
+	CursorBufferBlock CursorBufferPolicy = iota
+	// CursorBufferFail fails the cursor's request immediately
+	CursorBufferFail
+)
+
+// cursorBuffer is the configuration for the buffering of partial responses for each cursor
+type cursorBuffer struct {
+	size         int
+	holdLimit    int
+	policy       CursorBufferPolicy
+	blockTimeout time.Duration
+}
+
+// chunkHold is the partial responses held for a cursor whose buffer is full (see holdChunks)
+type chunkHold struct {
+	chunks int         // the number of partial responses held
+	timer  *time.Timer // fails the request at the block timeout, if any
+}
+
+// notifyChunk notifies the reader of cursor `id` of a partial response, applying the buffer policy when
+// its buffer (the capacity of chunkNotifier) is full. It never waits for the reader, as it is called
+// for the responses to every request on the connection.
+func (c *Client) notifyChunk(id string, chunkNotifier chan bool) {
+	c.Lock()
+	select {
+	case chunkNotifier <- true:
+		c.Unlock()
+		return
+	default:
+	}
+	if c.cursorBuffer.policy == CursorBufferBlock && c.holdChunks(id) {
+		c.Unlock()
+		return
+	}
+	c.Unlock()
+	c.failRequest(id, ErrorCursorBufferFull)
+}
+
+// holdChunks holds the partial response for cursor `id` beyond its buffer (it is saved with its results, and its
+// reader is already notified) until it is read, failing its request if that is not within the block timeout.
+// It returns false when the cursor already holds its limit of responses (the hold limit), so that the request fails.
+// Must be locked, as must the reading of the cursor, which releases the hold (see releaseChunks).
+func (c *Client) holdChunks(id string) bool {
+	if current, ok := c.chunkHolds.Load(id); ok {
+		hold := current.(*chunkHold)
+		if hold.chunks >= c.cursorBuffer.holdLimit {
+			return false
+		}
+		hold.chunks++
+		return true
+	}
+	if c.cursorBuffer.holdLimit < 1 {
+		return false
+	}
+	hold := &chunkHold{chunks: 1}
+	if c.cursorBuffer.blockTimeout > 0 {
+		hold.timer = time.AfterFunc(c.cursorBuffer.blockTimeout, func() {
+			c.Lock()
+			current, ok := c.chunkHolds.Load(id)
+			expired := ok && current == hold
+			if expired {
+				c.chunkHolds.Delete(id)
+			}
+			c.Unlock()
+			if expired {
+				c.failRequest(id, ErrorCursorBufferFull)
+			}
+		})
+	}
+	c.chunkHolds.Store(id, hold)
+	return true
+}
+
+// releaseChunks releases any hold on the partial responses for cursor `id` (see holdChunks)
+func (c *Client) releaseChunks(id string) {
+	if hold, ok := c.chunkHolds.LoadAndDelete(id); ok && hold.(*chunkHold).timer != nil {
+		hold.(*chunkHold).timer.Stop()
+	}
+}
+
+// failRequest discards the state held for the request, and any later responses to it (as for expireRequest),
+// but notifies its reader of err
+func (c *Client) failRequest(id string, err error) {
+	c.Lock()
+	respNotifier, ok := c.responseNotifier.Load(id)
+	req, tracked := c.inflight.Load(id)
+	c.Unlock()
+	c.expireRequest(id)
+	if !ok {
+		return
+	}
+	// restore the notifier, so that the reader receives err (it is then cleaned up, or reaped, as usual)
+	c.Lock()
+	c.responseNotifier.Store(id, respNotifier)
+	if tracked {
+		c.inflight.Store(id, req)
+		c.touchRequest(id)
+	}
+	c.Unlock()
+	select {
+	case respNotifier.(chan error) <- err:
+	default:
+	}
+}
+
+// closeCursor abandons the cursor's request (if not already complete), releasing its state
+func (c *Client) closeCursor(cursor *Cursor) {
+	if _, ok := c.responseNotifier.Load(cursor.ID); ok {
+		c.expireRequest(cursor.ID)
+	}
+}
+
+// ValueCursor is a Cursor which decodes each set of results (see DecodeGraphSON) as it arrives,
+// for queries returning any type of value, e.g. edges from `g.E()` or maps from `valueMap()`.
+// It reads from the client which opened it, so may be used for a cursor opened from a Pool.
+type ValueCursor struct {
+	Cursor
+	eof    bool
+	client Retriever
+}
+
+// NextValues returns the next set of results for the cursor
+// - `res` may be empty when results were read by a previous call (this is normal)
+// - `eof` will be true when no more results are available (`res` may still have results)
+func (vc *ValueCursor) NextValues(ctx context.Context) (res List, eof bool, err error) {
+	if vc.eof {
+		return nil, true, nil
+	}
+	var resp []Response
+	if resp, vc.eof, err = vc.client.retrieveNextResponseCtx(ctx, &vc.Cursor); err != nil {
+		return nil, false, errors.Wrapf(err, "NextValues: %s", vc.ID)
+	}
+	if res, err = DecodeResponses(resp); err != nil {
+		return nil, false, errors.Wrapf(err, "NextValues: %s", vc.ID)
+	}
+	return res, vc.eof, nil
+}
+
+// Next returns the next set of results for the cursor (see NextValues), passing them to decode
+func (vc *ValueCursor) Next(ctx context.Context, decode func(List) error) (eof bool, err error) {
+	var res List
+	if res, eof, err = vc.NextValues(ctx); err != nil {
+		return
+	}
+	if err = decode(res); err != nil {
+		err = errors.Wrapf(err, "Next: %s", vc.ID)
+	}
+	return
+}
+
+// NextVertices returns the next set of results for the cursor as vertices (see NextValues)
+func (vc *ValueCursor) NextVertices(ctx context.Context) (res []graphson.Vertex, eof bool, err error) {
+	eof, err = vc.Next(ctx, func(vals List) (err error) {
+		res, err = vals.Vertices()
+		return
+	})
+	return
+}
+
+// NextEdges returns the next set of results for the cursor as edges (see NextValues)
+func (vc *ValueCursor) NextEdges(ctx context.Context) (res []graphson.Edge, eof bool, err error) {
+	eof, err = vc.Next(ctx, func(vals List) (err error) {
+		res, err = vals.Edges()
+		return
+	})
+	return
+}
+
+// NextMaps returns the next set of results for the cursor as maps (see NextValues)
+func (vc *ValueCursor) NextMaps(ctx context.Context) (res []Map, eof bool, err error) {
+	eof, err = vc.Next(ctx, func(vals List) (err error) {
+		res, err = vals.Maps()
+		return
+	})
+	return
+}
+
+// Close releases the state held by the client for the cursor's request, when its results have not
+// all been read (any further responses for it are discarded)
+func (vc *ValueCursor) Close(ctx context.Context) error {
+	if !vc.eof {
+		vc.client.closeCursor(&vc.Cursor)
+	}
+	vc.eof = true
+	return nil
 }
 
 // Stream is a specific implementation of a Cursor, which iterates over results from a cursor but
 // only works on queries which return a list of strings. This is designed for returning what would
-// be considered 'rows' of data in other contexts.
+// be considered 'rows' of data in other contexts. Stream is an io.Reader and io.WriterTo of the rows,
+// each terminated by a newline, so it may be copied straight to e.g. an http.ResponseWriter or a file,
+// or its rows may be read one at a time with ReadLine.
+//
+// Further responses are retrieved from the database with the context the stream was opened with
+// (see OpenStreamCursor), or with the context given to ReadLineCtx.
 type Stream struct {
-	cursor *Cursor
-	eof    bool
-	buffer []string
-	client Retriever
+	cursor  *Cursor
+	eof     bool
+	buffer  []string
+	pending []byte // the unread remainder of the current row, for Read
+	ctx     context.Context
+	client  Retriever
+}
+
+func (s *Stream) context() context.Context {
+	if s.ctx == nil {
+		return context.Background()
+	}
+	return s.ctx
+}
+
+// ReadLine reads a string response (with a "\n" appended) from the stream cursor (see ReadLineCtx)
+func (s *Stream) ReadLine() (string, error) {
+	return s.ReadLineCtx(s.context())
+}
+
+// ReadLineCtx reads a string response (with a "\n" appended) from the stream cursor, reading from the buffer
+// of previously retrieved responses when possible. When the buffer is empty, ReadLineCtx uses the stream's client
+// to retrieve further responses from the database. At the end of the results, it returns io.EOF.
+// Any row partially read by Read is discarded.
+func (s *Stream) ReadLineCtx(ctx context.Context) (string, error) {
+	s.pending = nil
+	return s.readLine(ctx)
 }
 
-// Read a string response from the stream cursor, reading from the buffer of previously retrieved responses
-// when possible. When the buffer is empty, Read uses the stream's client to retrieve further
-// responses from the database.
-func (s *Stream) Read() (string, error) {
+func (s *Stream) readLine(ctx context.Context) (string, error) {
 	if len(s.buffer) == 0 {
 		if s.eof {
 			return "", io.EOF
 		}
 
-		if err := s.refillBuffer(); err != nil {
+		if err := s.refillBuffer(ctx); err != nil {
 			if err != io.EOF || len(s.buffer) == 0 {
 				return "", err
 			}
@@ -55,13 +284,62 @@ func (s *Stream) Read() (string, error) {
 
 }
 
-func (s *Stream) refillBuffer() error {
+// Read implements io.Reader, reading the rows of the stream into p. It only waits for further responses
+// from the database when nothing has been read into p.
+func (s *Stream) Read(p []byte) (n int, err error) {
+	for n < len(p) {
+		if len(s.pending) == 0 {
+			if n > 0 && len(s.buffer) == 0 {
+				return
+			}
+			var row string
+			if row, err = s.readLine(s.context()); err != nil {
+				return
+			}
+			s.pending = []byte(row)
+		}
+		copied := copy(p[n:], s.pending)
+		s.pending = s.pending[copied:]
+		n += copied
+	}
+	return
+}
+
+// WriteTo implements io.WriterTo, writing the (remaining) rows of the stream to w until the end of the results
+func (s *Stream) WriteTo(w io.Writer) (n int64, err error) {
+	if len(s.pending) > 0 {
+		var written int
+		written, err = w.Write(s.pending)
+		n += int64(written)
+		s.pending = s.pending[written:]
+		if err != nil {
+			return
+		}
+	}
+	for {
+		var row string
+		if row, err = s.readLine(s.context()); err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			return
+		}
+		var written int
+		written, err = io.WriteString(w, row)
+		n += int64(written)
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (s *Stream) refillBuffer(ctx context.Context) error {
 	var responses []Response
 	var err error
 
 	for responses == nil && !s.eof { //responses could be empty if reading too quickly
 
-		if responses, s.eof, err = s.client.retrieveNextResponseCtx(context.Background(), s.cursor); err != nil {
+		if responses, s.eof, err = s.client.retrieveNextResponseCtx(ctx, s.cursor); err != nil {
 			return errors.Wrapf(err, "stream.refillBuffer: %s", s.cursor.ID)
 		}
 
@@ -92,9 +370,14 @@ func (s *Stream) refillBuffer() error {
 	return nil
 }
 
-// Close satisfies the Closer interface. The stream does not need to close any
-// resources, as the contained client holds the connection and is responsible
-// for closing its own resources.
+// Close releases the state held by the client for the stream's request, when its results have not
+// all been read (any further responses for it are discarded). Reading after Close returns io.EOF.
 func (s *Stream) Close(ctx context.Context) error {
+	if !s.eof && s.cursor != nil {
+		s.client.closeCursor(s.cursor)
+	}
+	s.eof = true
+	s.buffer = nil
+	s.pending = nil
 	return nil
 }
@@ -1,12 +1,17 @@
 package gremgo
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"io"
 	"net/http"
 	"strconv"
 	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/pkg/errors"
 )
 
 func TestStreamRead(t *testing.T) {
@@ -14,7 +19,7 @@ func TestStreamRead(t *testing.T) {
 	rowContent := "example,row,content,"
 	expectedRow := rowContent + "\n"
 
-	cursor := &Cursor{"cursorId"}
+	cursor := &Cursor{ID: "cursorId"}
 
 	// return a single string response when retrieve is called
 	retriever := &RetrieverMock{
@@ -34,7 +39,7 @@ func TestStreamRead(t *testing.T) {
 	}
 
 	// first call to read should return the row
-	got, err := s.Read()
+	got, err := s.ReadLine()
 	if err != nil {
 		t.Errorf("Read() error = %v, wantNilErr", err)
 		return
@@ -45,7 +50,7 @@ func TestStreamRead(t *testing.T) {
 	}
 
 	// the second call to read should return EOF error
-	got, err = s.Read()
+	got, err = s.ReadLine()
 	if err != io.EOF {
 		t.Errorf("Read() error = %v, want %v", err, io.EOF)
 		return
@@ -59,7 +64,7 @@ func TestStreamRead(t *testing.T) {
 func TestStreamRead_MultipleResponsesAtOnce(t *testing.T) {
 
 	rowContent := "example,row,content,"
-	cursor := &Cursor{"cursorId"}
+	cursor := &Cursor{ID: "cursorId"}
 
 	retriever := &RetrieverMock{
 		retrieveNextResponseCtxFunc: func(ctx context.Context, cursor *Cursor) (responses []Response, eof bool, err error) {
@@ -82,7 +87,7 @@ func TestStreamRead_MultipleResponsesAtOnce(t *testing.T) {
 
 	// the first call to read should return a row
 	expectedRow := rowContent + "1\n"
-	got, err := s.Read()
+	got, err := s.ReadLine()
 	if err != nil {
 		t.Errorf("Read() error = %v, wantNilErr", err)
 		return
@@ -94,7 +99,7 @@ func TestStreamRead_MultipleResponsesAtOnce(t *testing.T) {
 
 	// the second call to read should return a row
 	expectedRow = rowContent + "2\n"
-	got, err = s.Read()
+	got, err = s.ReadLine()
 	if err != nil {
 		t.Errorf("Read() error = %v, wantNilErr", err)
 		return
@@ -105,7 +110,7 @@ func TestStreamRead_MultipleResponsesAtOnce(t *testing.T) {
 	}
 
 	// the third call to read should return EOF error
-	got, err = s.Read()
+	got, err = s.ReadLine()
 	if err != io.EOF {
 		t.Errorf("Read() error = %v, want %v", err, io.EOF)
 		return
@@ -119,7 +124,7 @@ func TestStreamRead_MultipleResponsesAtOnce(t *testing.T) {
 func TestStreamRead_MultipleResponses(t *testing.T) {
 
 	rowContent := "example,row,content,"
-	cursor := &Cursor{"cursorId"}
+	cursor := &Cursor{ID: "cursorId"}
 
 	retrieveCallCount := 0
 
@@ -154,7 +159,7 @@ func TestStreamRead_MultipleResponses(t *testing.T) {
 
 	// the first call to read should return a row
 	expectedRow := rowContent + "1\n"
-	got, err := s.Read()
+	got, err := s.ReadLine()
 	if err != nil {
 		t.Errorf("Read() error = %v, wantNilErr", err)
 		return
@@ -166,7 +171,7 @@ func TestStreamRead_MultipleResponses(t *testing.T) {
 
 	// the second call to read should return a row
 	expectedRow = rowContent + "2\n"
-	got, err = s.Read()
+	got, err = s.ReadLine()
 	if err != nil {
 		t.Errorf("Read() error = %v, wantNilErr", err)
 		return
@@ -177,7 +182,7 @@ func TestStreamRead_MultipleResponses(t *testing.T) {
 	}
 
 	// the third call to read should return EOF error
-	got, err = s.Read()
+	got, err = s.ReadLine()
 	if err != io.EOF {
 		t.Errorf("Read() error = %v, want %v", err, io.EOF)
 		return
@@ -191,7 +196,7 @@ func TestStreamRead_MultipleResponses(t *testing.T) {
 func TestStreamRead_EmptyLastResponse(t *testing.T) {
 
 	rowContent := "example,row,content,"
-	cursor := &Cursor{"cursorId"}
+	cursor := &Cursor{ID: "cursorId"}
 
 	retrieveCallCount := 0
 
@@ -224,7 +229,7 @@ func TestStreamRead_EmptyLastResponse(t *testing.T) {
 
 	// the first call to read should return a row
 	expectedRow := rowContent + "1\n"
-	got, err := s.Read()
+	got, err := s.ReadLine()
 	if err != nil {
 		t.Errorf("Read() error = %v, wantNilErr", err)
 		return
@@ -235,7 +240,7 @@ func TestStreamRead_EmptyLastResponse(t *testing.T) {
 	}
 
 	// the second call to read should return EOF error
-	got, err = s.Read()
+	got, err = s.ReadLine()
 	if err != io.EOF {
 		t.Errorf("Read() error = %v, want %v", err, io.EOF)
 		return
@@ -248,7 +253,7 @@ func TestStreamRead_EmptyLastResponse(t *testing.T) {
 
 func TestStreamRead_NoContentResponse(t *testing.T) {
 
-	cursor := &Cursor{"cursorId"}
+	cursor := &Cursor{ID: "cursorId"}
 
 	retriever := &RetrieverMock{
 		retrieveNextResponseCtxFunc: func(ctx context.Context, cursor *Cursor) (responses []Response, eof bool, err error) {
@@ -273,7 +278,7 @@ func TestStreamRead_NoContentResponse(t *testing.T) {
 	}
 
 	// the call to read should return EOF error
-	got, err := s.Read()
+	got, err := s.ReadLine()
 	if err != io.EOF {
 		t.Errorf("Read() error = %v, want %v", err, io.EOF)
 		return
@@ -293,3 +298,387 @@ func createResponse(rowContent string) Response {
 		},
 	}
 }
+
+func partialResponse(id string) Response {
+	return Response{RequestID: id, Status: Status{Code: StatusPartialContent}, Result: Result{Data: []byte(`{"@type":"g:List","@value":["row"]}`)}}
+}
+
+// readCursorAll reads the cursor until done (or an error), returning the number of partial responses read
+func readCursorAll(c *Client, cursor *Cursor, pause time.Duration) (chunks int, err error) {
+	for done := false; !done; {
+		var data []Response
+		if data, done, err = c.retrieveNextResponseCtx(context.Background(), cursor); err != nil {
+			return
+		}
+		for _, resp := range data {
+			if resp.Status.Code == StatusPartialContent {
+				chunks++
+			}
+		}
+		time.Sleep(pause)
+	}
+	return
+}
+
+func TestCursorBufferFail(t *testing.T) {
+	c := newClient()
+	SetCursorBufferSize(5)(c)
+	SetCursorBufferPolicy(CursorBufferFail)(c)
+	stop := drainRequests(t, c)
+	defer stop()
+
+	slow, err := c.executeRequestCursorCtx(context.Background(), "g.V()", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := c.executeRequestCursorCtx(context.Background(), "g.E()", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the slow cursor is never read, but must not hold up the other request
+	saved := make(chan struct{})
+	go func() {
+		defer close(saved)
+		for i := 0; i < 8; i++ {
+			c.saveResponse(partialResponse(slow.ID), nil)
+			if i < 4 {
+				c.saveResponse(partialResponse(other.ID), nil)
+			}
+		}
+		c.saveResponse(Response{RequestID: slow.ID, Status: Status{Code: StatusSuccess}}, nil)
+		c.saveResponse(Response{RequestID: other.ID, Status: Status{Code: StatusSuccess}}, nil)
+	}()
+
+	if chunks, err := readCursorAll(c, other, 0); err != nil || chunks != 4 {
+		t.Errorf("Expected 4 chunks for the other request, got %d %v", chunks, err)
+	}
+	select {
+	case <-saved:
+	case <-time.After(time.Second):
+		t.Fatal("Expected responses to be saved without blocking on the full cursor")
+	}
+
+	if _, err := readCursorAll(c, slow, 0); err != ErrorCursorBufferFull {
+		t.Errorf("Expected %v, got %v", ErrorCursorBufferFull, err)
+	}
+	assertNoRequestState(t, c)
+}
+
+func TestCursorBufferBlock(t *testing.T) {
+	c := newClient()
+	SetCursorBufferSize(2)(c)
+	SetCursorBlockTimeout(100 * time.Millisecond)(c)
+	stop := drainRequests(t, c)
+	defer stop()
+
+	t.Run("reader keeps up", func(t *testing.T) {
+		cursor, err := c.executeRequestCursorCtx(context.Background(), "g.V()", nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		go func() {
+			for i := 0; i < 20; i++ {
+				c.saveResponse(partialResponse(cursor.ID), nil)
+			}
+			c.saveResponse(Response{RequestID: cursor.ID, Status: Status{Code: StatusSuccess}}, nil)
+		}()
+		if chunks, err := readCursorAll(c, cursor, time.Millisecond); err != nil || chunks != 20 {
+			t.Errorf("Expected all 20 chunks, got %d %v", chunks, err)
+		}
+		assertNoRequestState(t, c)
+	})
+
+	t.Run("reader too slow", func(t *testing.T) {
+		cursor, err := c.executeRequestCursorCtx(context.Background(), "g.V()", nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		other, err := c.executeRequestCursorCtx(context.Background(), "g.E()", nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			c.saveResponse(partialResponse(cursor.ID), nil)
+		}
+		// the full cursor holds up neither the saving of responses, nor the other request
+		c.saveResponse(partialResponse(other.ID), nil)
+		c.saveResponse(Response{RequestID: other.ID, Status: Status{Code: StatusSuccess}}, nil)
+		if chunks, err := readCursorAll(c, other, 0); err != nil || chunks != 1 {
+			t.Errorf("Expected the chunk of the other request, got %d %v", chunks, err)
+		}
+		if waited := time.Since(start); waited >= 100*time.Millisecond {
+			t.Errorf("Expected not to wait for the full cursor, waited %v", waited)
+		}
+
+		time.Sleep(150 * time.Millisecond)
+		if _, err := readCursorAll(c, cursor, 0); !errors.Is(err, ErrorCursorBufferFull) {
+			t.Errorf("Expected %v, got %v", ErrorCursorBufferFull, err)
+		}
+		assertNoRequestState(t, c)
+	})
+
+	t.Run("reader catches up within the block timeout", func(t *testing.T) {
+		cursor, err := c.executeRequestCursorCtx(context.Background(), "g.V()", nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 3; i++ {
+			c.saveResponse(partialResponse(cursor.ID), nil)
+		}
+		if data, _, err := c.retrieveNextResponseCtx(context.Background(), cursor); err != nil || len(data) != 3 {
+			t.Fatalf("Expected the 3 held chunks, got %d %v", len(data), err)
+		}
+		time.Sleep(150 * time.Millisecond)
+		c.saveResponse(Response{RequestID: cursor.ID, Status: Status{Code: StatusSuccess}}, nil)
+		if _, err := readCursorAll(c, cursor, 0); err != nil {
+			t.Errorf("Expected the cursor not to fail once read, got %v", err)
+		}
+		assertNoRequestState(t, c)
+	})
+}
+
+func TestCursorHoldLimit(t *testing.T) {
+	c := newClient() // no block timeout
+	SetCursorBufferSize(2)(c)
+	SetCursorHoldLimit(3)(c)
+	stop := drainRequests(t, c)
+	defer stop()
+
+	cursor, err := c.executeRequestCursorCtx(context.Background(), "g.V()", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := c.executeRequestCursorCtx(context.Background(), "g.E()", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the cursor is never read, so the responses held for it stop at its buffer and hold limit
+	for i := 0; i < 100; i++ {
+		c.saveResponse(partialResponse(cursor.ID), nil)
+		c.saveResponse(partialResponse(other.ID), nil)
+		if held := unreadResponses(c, cursor.ID); held > 2+3 {
+			t.Fatalf("Expected at most 5 unread responses held, got %d", held)
+		}
+		if i%2 == 1 {
+			if _, _, err := c.retrieveNextResponseCtx(context.Background(), other); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if _, err := readCursorAll(c, cursor, 0); !errors.Is(err, ErrorCursorBufferFull) {
+		t.Errorf("Expected %v, got %v", ErrorCursorBufferFull, err)
+	}
+
+	// the other request, read as its responses arrive, is unaffected
+	c.saveResponse(Response{RequestID: other.ID, Status: Status{Code: StatusSuccess}}, nil)
+	if _, err := readCursorAll(c, other, 0); err != nil {
+		t.Errorf("Expected the other request to complete, got %v", err)
+	}
+	assertNoRequestState(t, c)
+}
+
+// unreadResponses returns the number of responses held for request id
+func unreadResponses(c *Client, id string) int {
+	c.Lock()
+	defer c.Unlock()
+	results, _ := c.results.Load(id)
+	held, _ := results.([]interface{})
+	return len(held)
+}
+
+func TestValueCursor(t *testing.T) {
+	edges := `{"@type":"g:List","@value":[{"@type":"g:Edge","@value":{"id":"e1","label":"hasEdition","inVLabel":"edition","outVLabel":"dataset","inV":"v2","outV":"v1"}}]}`
+	responses := []Response{
+		{Status: Status{Code: StatusPartialContent}, Result: Result{Data: json.RawMessage(edges)}},
+		{Status: Status{Code: StatusSuccess}, Result: Result{Data: json.RawMessage(dummyElementMaps)}},
+	}
+	calls := 0
+	vc := &ValueCursor{
+		Cursor: Cursor{ID: "cursorId"},
+		client: &RetrieverMock{
+			retrieveNextResponseCtxFunc: func(ctx context.Context, cursor *Cursor) ([]Response, bool, error) {
+				calls++
+				return responses[calls-1 : calls], calls == len(responses), nil
+			},
+		},
+	}
+	ctx := context.Background()
+
+	res, eof, err := vc.NextEdges(ctx)
+	if err != nil || eof {
+		t.Fatalf("Expected edges without eof, got eof=%v %v", eof, err)
+	}
+	if len(res) != 1 || res[0].Value.ID != "e1" || res[0].Value.InV != "v2" {
+		t.Errorf("Unexpected edges %+v", res)
+	}
+
+	maps, eof, err := vc.NextMaps(ctx)
+	if err != nil || !eof {
+		t.Fatalf("Expected maps with eof, got eof=%v %v", eof, err)
+	}
+	if name, _ := maps[0].GetString("name"); name != "cpih" {
+		t.Errorf("Unexpected maps %+v", maps)
+	}
+
+	// reading at eof does not retrieve again
+	vals, eof, err := vc.NextValues(ctx)
+	if err != nil || !eof || len(vals) != 0 || calls != len(responses) {
+		t.Errorf("Expected nothing more at eof, got %v eof=%v %v (%d calls)", vals, eof, err, calls)
+	}
+}
+
+func TestValueCursorErrors(t *testing.T) {
+	ctx := context.Background()
+	boom := errors.New("boom")
+	vc := &ValueCursor{
+		Cursor: Cursor{ID: "cursorId"},
+		client: &RetrieverMock{
+			retrieveNextResponseCtxFunc: func(ctx context.Context, cursor *Cursor) ([]Response, bool, error) {
+				return nil, false, boom
+			},
+		},
+	}
+	if _, _, err := vc.NextValues(ctx); errors.Cause(err) != boom {
+		t.Errorf("Expected %v, got %v", boom, err)
+	}
+
+	vc = &ValueCursor{
+		Cursor: Cursor{ID: "cursorId"},
+		client: &RetrieverMock{
+			retrieveNextResponseCtxFunc: func(ctx context.Context, cursor *Cursor) ([]Response, bool, error) {
+				return []Response{{Status: Status{Code: StatusSuccess}, Result: Result{Data: json.RawMessage(dummyElementMaps)}}}, true, nil
+			},
+		},
+	}
+	if _, _, err := vc.NextEdges(ctx); !errors.Is(err, ErrorUnexpectedValueType) {
+		t.Errorf("Expected %v, got %v", ErrorUnexpectedValueType, err)
+	}
+}
+
+func TestPoolValueCursor(t *testing.T) {
+	errs := make(chan error)
+	p, _ := MockNewPoolWithDialerCtx(context.Background(), "ws://0", errs, t, nil, []StaggeredResponse{
+		{response: Response{Status: Status{Code: StatusPartialContent}, Result: Result{Data: json.RawMessage(dummyElementMaps)}}},
+		{after: 50 * time.Millisecond, response: Response{Status: Status{Code: StatusSuccess}, Result: Result{Data: json.RawMessage(dummyElementMaps)}}},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	vc, err := p.OpenValueCursorCtx(ctx, "g.V().elementMap()", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var total int
+	for eof := false; !eof; {
+		var maps []Map
+		if maps, eof, err = vc.NextMaps(ctx); err != nil {
+			t.Fatal(err)
+		}
+		total += len(maps)
+	}
+	if total != 2 {
+		t.Errorf("Expected 2 maps, got %d", total)
+	}
+}
+
+func stringListRetriever(chunks ...[]string) *RetrieverMock {
+	calls := 0
+	return &RetrieverMock{
+		retrieveNextResponseCtxFunc: func(ctx context.Context, cursor *Cursor) ([]Response, bool, error) {
+			if err := ctx.Err(); err != nil {
+				return nil, false, err
+			}
+			data, _ := json.Marshal(chunks[calls])
+			calls++
+			resp := Response{Status: Status{Code: StatusPartialContent}, Result: Result{Data: json.RawMessage(`{"@type":"g:List","@value":` + string(data) + `}`)}}
+			return []Response{resp}, calls == len(chunks), nil
+		},
+		closeCursorFunc: func(cursor *Cursor) {},
+	}
+}
+
+func TestStreamReader(t *testing.T) {
+	var _ io.Reader = &Stream{}
+	expected := "a,1\nb,2\nc,3\n"
+
+	s := &Stream{cursor: &Cursor{ID: "cursorId"}, client: stringListRetriever([]string{"a,1", "b,2"}, []string{"c,3"})}
+	got, err := io.ReadAll(iotest.OneByteReader(s))
+	if err != nil || string(got) != expected {
+		t.Errorf("Read: expected %q, got %q %v", expected, got, err)
+	}
+
+	s = &Stream{cursor: &Cursor{ID: "cursorId"}, client: stringListRetriever([]string{"a,1", "b,2"}, []string{"c,3"})}
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, s)
+	if err != nil || buf.String() != expected || n != int64(len(expected)) {
+		t.Errorf("WriteTo: expected %q, got %q (%d) %v", expected, buf.String(), n, err)
+	}
+
+	// a partially read row is written first
+	s = &Stream{cursor: &Cursor{ID: "cursorId"}, client: stringListRetriever([]string{"a,1", "b,2"}, []string{"c,3"})}
+	p := make([]byte, 2)
+	if _, err = s.Read(p); err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if _, err = s.WriteTo(&buf); err != nil || string(p)+buf.String() != expected {
+		t.Errorf("Read then WriteTo: expected %q, got %q %v", expected, string(p)+buf.String(), err)
+	}
+}
+
+func TestStreamContext(t *testing.T) {
+	// the context the stream was opened with
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s := &Stream{cursor: &Cursor{ID: "cursorId"}, ctx: ctx, client: stringListRetriever([]string{"a"})}
+	if _, err := io.ReadAll(s); errors.Cause(err) != context.Canceled {
+		t.Errorf("Expected %v, got %v", context.Canceled, err)
+	}
+	if _, err := s.ReadLine(); errors.Cause(err) != context.Canceled {
+		t.Errorf("Expected %v, got %v", context.Canceled, err)
+	}
+
+	// or given for each row
+	s = &Stream{cursor: &Cursor{ID: "cursorId"}, ctx: ctx, client: stringListRetriever([]string{"a"})}
+	if row, err := s.ReadLineCtx(context.Background()); err != nil || row != "a\n" {
+		t.Errorf("Expected the row, got %q %v", row, err)
+	}
+}
+
+func TestStreamClose(t *testing.T) {
+	retriever := stringListRetriever([]string{"a"}, []string{"b"})
+	s := &Stream{cursor: &Cursor{ID: "cursorId"}, client: retriever}
+	if row, err := s.ReadLine(); err != nil || row != "a\n" {
+		t.Fatalf("Expected first row, got %q %v", row, err)
+	}
+	if err := s.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(retriever.closeCursorCalls()); n != 1 {
+		t.Errorf("Expected the cursor to be closed once, got %d", n)
+	}
+	if _, err := s.ReadLine(); err != io.EOF {
+		t.Errorf("Expected %v after Close, got %v", io.EOF, err)
+	}
+	s.Close(context.Background())
+	if n := len(retriever.closeCursorCalls()); n != 1 {
+		t.Errorf("Expected a closed stream not to close the cursor again, got %d", n)
+	}
+
+	// the client state for the request is released
+	c := newClient()
+	stop := drainRequests(t, c)
+	defer stop()
+	cursor, err := c.executeRequestCursorCtx(context.Background(), "g.V().values('name')", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.saveResponse(partialResponse(cursor.ID), nil)
+	s = &Stream{cursor: cursor, client: c}
+	s.Close(context.Background())
+	lateResponses(c, []string{cursor.ID})
+	assertNoRequestState(t, c)
+}
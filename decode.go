@@ -0,0 +1,634 @@
+package gremgo
+
+import (
+	"encoding"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/ONSdigital/graphson"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrorDecodeDestination     = errors.New("destination must be a non-nil pointer to a slice of structs (or of pointers to structs)")
+	ErrorDecodeDestinationItem = errors.New("destination must be a non-nil pointer to a struct")
+)
+
+// DecodeTypeError describes a vertex value which could not be decoded into a struct field
+type DecodeTypeError struct {
+	Field    string       // the struct field
+	Property string       // the vertex property (or "id", "label")
+	Value    interface{}  // the value which could not be decoded
+	Type     reflect.Type // the type of the struct field
+	Reason   string       // optional detail
+}
+
+func (e *DecodeTypeError) Error() string {
+	msg := fmt.Sprintf("cannot decode property %q value %v (%T) into field %q of type %s", e.Property, e.Value, e.Value, e.Field, e.Type)
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return msg
+}
+
+// DecodeVertices decodes verts into dest, which must be a pointer to a slice of structs (or of pointers to structs).
+// The struct fields are populated using the same `graph` tags as GremlinForVertex, with the addition of
+// the `label` option for the vertex label, so a tag without a type option infers it from the field, embedded
+// structs are populated as if their fields were those of the struct, and a map[string]T field is populated from
+// the properties "<name>.<key>". Properties missing from a vertex leave the field as its zero value (or nil pointer).
+// Fields (and structs) which are GraphUnmarshalers decode themselves.
+func DecodeVertices(verts []graphson.Vertex, dest interface{}) error {
+	return decodeSlice(len(verts), dest, func(i int, item reflect.Value) error {
+		return errors.Wrapf(decodeVertexValue(verts[i], item), "vertex %q", verts[i].GetID())
+	})
+}
+
+// decodeSlice sets dest, which must be a pointer to a slice of structs (or of pointers to structs), to n items,
+// each populated by decodeItem
+func decodeSlice(n int, dest interface{}, decodeItem func(i int, item reflect.Value) error) error {
+	d := reflect.ValueOf(dest)
+	if d.Kind() != reflect.Ptr || d.IsNil() || d.Elem().Kind() != reflect.Slice {
+		return ErrorDecodeDestination
+	}
+	sliceType := d.Elem().Type()
+	itemType := sliceType.Elem()
+	isPtr := itemType.Kind() == reflect.Ptr
+	if isPtr {
+		itemType = itemType.Elem()
+	}
+	if itemType.Kind() != reflect.Struct {
+		return ErrorDecodeDestination
+	}
+
+	res := reflect.MakeSlice(sliceType, n, n)
+	for i := 0; i < n; i++ {
+		item := reflect.New(itemType)
+		if err := decodeItem(i, item.Elem()); err != nil {
+			return err
+		}
+		if isPtr {
+			res.Index(i).Set(item)
+		} else {
+			res.Index(i).Set(item.Elem())
+		}
+	}
+	d.Elem().Set(res)
+	return nil
+}
+
+// DecodeVertex decodes vert into dest, which must be a pointer to a struct (see DecodeVertices)
+func DecodeVertex(vert graphson.Vertex, dest interface{}) error {
+	d := reflect.ValueOf(dest)
+	if d.Kind() != reflect.Ptr || d.IsNil() || d.Elem().Kind() != reflect.Struct {
+		return ErrorDecodeDestinationItem
+	}
+	return decodeVertexValue(vert, d.Elem())
+}
+
+// decodeVertexValue populates the fields of the struct d from vert
+func decodeVertexValue(vert graphson.Vertex, d reflect.Value) error {
+	props := lazyProperties(func() (map[string][]interface{}, error) { return vertexProperties(vert) })
+	if isGraphUnmarshaler(d) {
+		p, err := props()
+		if err != nil {
+			return err
+		}
+		return unmarshalGraph(d, propertiesMap(p))
+	}
+	return decodeVertexFields(vert, d, props)
+}
+
+// decodeVertexFields populates the fields of the struct d (and of its embedded structs) from vert
+func decodeVertexFields(vert graphson.Vertex, d reflect.Value, props elementProperties) error {
+	t := d.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("graph")
+		name, opts := parseTag(tag)
+		if (len(name) == 0 || name == "-") && len(opts) == 0 {
+			if len(tag) == 0 && field.Name == "Id" {
+				if err := decodeScalar(d.Field(i), field, "id", "string", vert.GetID()); err != nil {
+					return err
+				}
+			} else if len(tag) == 0 && field.Anonymous && field.IsExported() {
+				if err := decodeEmbedded(d.Field(i), func(fv reflect.Value) error { return decodeVertexFields(vert, fv, props) }); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			return fmt.Errorf("interface field tag %q is on unexported field: %q", name, field.Name)
+		}
+		if !opts.Contains("id") && !opts.Contains("label") && isGraphUnmarshaler(d.Field(i)) {
+			p, err := props()
+			if err != nil {
+				return err
+			}
+			if val, ok := unmarshalValue(p, name); ok {
+				if err := unmarshalGraph(d.Field(i), val); err != nil {
+					return errors.Wrapf(err, "property %q", name)
+				}
+			}
+			continue
+		}
+
+		var err error
+		if opts.Contains("id") {
+			err = decodeScalar(d.Field(i), field, "id", "string", vert.GetID())
+		} else if opts.Contains("label") {
+			err = decodeScalar(d.Field(i), field, "label", "string", vert.Value.Label)
+		} else if kind := scalarOption(opts); kind != "" {
+			var vals []interface{}
+			if vals, err = vertexPropertyValues(vert, name); err != nil {
+				return errors.Wrapf(err, "property %q", name)
+			} else if len(vals) > 1 {
+				return &DecodeTypeError{Field: field.Name, Property: name, Value: vals, Type: field.Type, Reason: "multiple values for single-valued field"}
+			} else if len(vals) == 1 {
+				err = decodeScalar(d.Field(i), field, name, kind, vals[0])
+			}
+		} else if kind := listOption(opts); kind != "" {
+			var vals []interface{}
+			if vals, err = vertexPropertyValues(vert, name); err != nil {
+				return errors.Wrapf(err, "property %q", name)
+			}
+			err = decodeList(d.Field(i), field, name, kind, vals)
+		} else if hasOnlyModifiers(opts) {
+			var p map[string][]interface{}
+			if p, err = props(); err != nil {
+				return err
+			}
+			err = decodeInferred(d.Field(i), field, name, p)
+		} else {
+			return fmt.Errorf("interface field tag needs recognised option, field: %q, tag: %q", field.Name, tag)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DecodeEdges decodes edges into dest, which must be a pointer to a slice of structs (or of pointers to structs).
+// The struct fields are populated using the same `graph` tags as GremlinForEdge: the `from` and `to` options
+// for the ids of the outgoing and incoming vertices, `id`, `label`, the single-valued property options, and tags
+// without a type option, embedded structs and map[string]T fields (as for DecodeVertices).
+func DecodeEdges(edges []graphson.Edge, dest interface{}) error {
+	return decodeSlice(len(edges), dest, func(i int, item reflect.Value) error {
+		return errors.Wrapf(decodeEdgeValue(edges[i], item), "edge %q", edges[i].Value.ID)
+	})
+}
+
+// DecodeEdge decodes edge into dest, which must be a pointer to a struct (see DecodeEdges)
+func DecodeEdge(edge graphson.Edge, dest interface{}) error {
+	d := reflect.ValueOf(dest)
+	if d.Kind() != reflect.Ptr || d.IsNil() || d.Elem().Kind() != reflect.Struct {
+		return ErrorDecodeDestinationItem
+	}
+	return decodeEdgeValue(edge, d.Elem())
+}
+
+// decodeEdgeValue populates the fields of the struct d from edge
+func decodeEdgeValue(edge graphson.Edge, d reflect.Value) error {
+	props := lazyProperties(func() (map[string][]interface{}, error) { return edgeProperties(edge) })
+	if isGraphUnmarshaler(d) {
+		p, err := props()
+		if err != nil {
+			return err
+		}
+		return unmarshalGraph(d, propertiesMap(p))
+	}
+	return decodeEdgeFields(edge, d, props)
+}
+
+// decodeEdgeFields populates the fields of the struct d (and of its embedded structs) from edge
+func decodeEdgeFields(edge graphson.Edge, d reflect.Value, props elementProperties) error {
+	t := d.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("graph")
+		name, opts := parseTag(tag)
+		if (len(name) == 0 || name == "-") && len(opts) == 0 {
+			if len(tag) == 0 && field.Name == "Id" {
+				if err := decodeScalar(d.Field(i), field, "id", "string", edge.Value.ID); err != nil {
+					return err
+				}
+			} else if len(tag) == 0 && field.Anonymous && field.IsExported() {
+				if err := decodeEmbedded(d.Field(i), func(fv reflect.Value) error { return decodeEdgeFields(edge, fv, props) }); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			return fmt.Errorf("interface field tag %q is on unexported field: %q", name, field.Name)
+		}
+		if !opts.Contains("id") && !opts.Contains("label") && !opts.Contains("from") && !opts.Contains("to") && isGraphUnmarshaler(d.Field(i)) {
+			p, err := props()
+			if err != nil {
+				return err
+			}
+			if val, ok := unmarshalValue(p, name); ok {
+				if err := unmarshalGraph(d.Field(i), val); err != nil {
+					return errors.Wrapf(err, "property %q", name)
+				}
+			}
+			continue
+		}
+
+		var err error
+		if opts.Contains("id") {
+			err = decodeScalar(d.Field(i), field, "id", "string", edge.Value.ID)
+		} else if opts.Contains("label") {
+			err = decodeScalar(d.Field(i), field, "label", "string", edge.Value.Label)
+		} else if opts.Contains("from") {
+			err = decodeScalar(d.Field(i), field, "from", "string", edge.Value.OutV)
+		} else if opts.Contains("to") {
+			err = decodeScalar(d.Field(i), field, "to", "string", edge.Value.InV)
+		} else if kind := scalarOption(opts); kind != "" {
+			prop, ok := edge.Value.Properties[name]
+			if !ok {
+				continue
+			}
+			var val interface{}
+			if val, err = DecodeGraphSON(prop.Value.Value); err != nil {
+				return errors.Wrapf(err, "property %q", name)
+			}
+			err = decodeScalar(d.Field(i), field, name, kind, val)
+		} else if listOption(opts) != "" {
+			return fmt.Errorf("interface field tag %q has a slice option, but edge properties are single-valued", name)
+		} else if hasOnlyModifiers(opts) {
+			var p map[string][]interface{}
+			if p, err = props(); err != nil {
+				return err
+			}
+			err = decodeInferred(d.Field(i), field, name, p)
+		} else {
+			return fmt.Errorf("interface field tag needs recognised option, field: %q, tag: %q", field.Name, tag)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// elementProperties returns the (decoded) values of each property of a vertex or edge
+type elementProperties func() (map[string][]interface{}, error)
+
+// lazyProperties returns the elementProperties from decode, which is only called (once) when they are first needed
+func lazyProperties(decode func() (map[string][]interface{}, error)) elementProperties {
+	var props map[string][]interface{}
+	var err error
+	var decoded bool
+	return func() (map[string][]interface{}, error) {
+		if !decoded {
+			props, err = decode()
+			decoded = true
+		}
+		return props, err
+	}
+}
+
+// decodeEmbedded populates the embedded struct fv with decodeFields, as its fields are written as if they were
+// fields of the outer struct (see readFields). A nil pointer, which is not written, is left nil unless any of
+// its fields is decoded as a non-zero value.
+func decodeEmbedded(fv reflect.Value, decodeFields func(fv reflect.Value) error) error {
+	t := fv.Type()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == timeType {
+		return nil
+	}
+	if fv.Kind() != reflect.Ptr {
+		return decodeFields(fv)
+	}
+	if !fv.IsNil() {
+		return decodeFields(fv.Elem())
+	}
+	embedded := reflect.New(t)
+	if err := decodeFields(embedded.Elem()); err != nil {
+		return err
+	}
+	if !embedded.Elem().IsZero() {
+		fv.Set(embedded)
+	}
+	return nil
+}
+
+// decodeInferred sets fv (for `field`) from the values of the property `name` in props, for a tag without a type
+// option, inferring the type from the field as when it is written (see appendProperties): a pointer is set only
+// when the property has a value, a slice from each value, and a map[string]T from the properties "<name>.<key>"
+func decodeInferred(fv reflect.Value, field reflect.StructField, name string, props map[string][]interface{}) error {
+	t := fv.Type()
+	if t.Kind() == reflect.Map {
+		return decodeInferredMap(fv, field, name, props)
+	}
+	vals := props[name]
+	if isList(fv) && t != rawMessageType && !isTextUnmarshaler(fv) {
+		if t.Kind() == reflect.Array {
+			if len(vals) > fv.Len() {
+				return &DecodeTypeError{Field: field.Name, Property: name, Value: vals, Type: t, Reason: "too many values for array"}
+			}
+			for i, val := range vals {
+				if err := decodeInferredValue(fv.Index(i), field, name, val); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if len(vals) == 0 {
+			return nil
+		}
+		s := reflect.MakeSlice(t, len(vals), len(vals))
+		for i, val := range vals {
+			if err := decodeInferredValue(s.Index(i), field, name, val); err != nil {
+				return err
+			}
+		}
+		fv.Set(s)
+		return nil
+	}
+	if len(vals) > 1 {
+		return &DecodeTypeError{Field: field.Name, Property: name, Value: vals, Type: t, Reason: "multiple values for single-valued field"}
+	} else if len(vals) == 0 {
+		return nil
+	}
+	return decodeInferredValue(fv, field, name, vals[0])
+}
+
+// decodeInferredMap sets the map[string]T fv from the properties "<name>.<key>" in props, each decoded as for
+// decodeInferred (for a T which is itself a map, its keys follow the first "." of the key)
+func decodeInferredMap(fv reflect.Value, field reflect.StructField, name string, props map[string][]interface{}) error {
+	t := fv.Type()
+	if t.Key().Kind() != reflect.String {
+		return &DecodeTypeError{Field: field.Name, Property: name, Type: t, Reason: "map key must be a string"}
+	}
+	prefix := name + "."
+	keys := make(map[string]bool)
+	for prop := range props {
+		key := strings.TrimPrefix(prop, prefix)
+		if key == prop {
+			continue
+		}
+		if t.Elem().Kind() == reflect.Map {
+			key = strings.SplitN(key, ".", 2)[0]
+		}
+		keys[key] = true
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	m := reflect.MakeMapWithSize(t, len(keys))
+	for key := range keys {
+		item := reflect.New(t.Elem()).Elem()
+		if err := decodeInferred(item, field, prefix+key, props); err != nil {
+			return err
+		}
+		m.SetMapIndex(reflect.ValueOf(key).Convert(t.Key()), item)
+	}
+	fv.Set(m)
+	return nil
+}
+
+// decodeInferredValue sets fv (for `field`, or an element of it) to val, inferring its type from fv: a time.Time,
+// uuid.UUID (or other value of the field's type), a TextUnmarshaler from its text, a json.RawMessage from its JSON,
+// or a string, bool or number (see decodeScalar)
+func decodeInferredValue(fv reflect.Value, field reflect.StructField, prop string, val interface{}) error {
+	if isGraphUnmarshaler(fv) {
+		return decodeScalar(fv, field, prop, "other", val)
+	}
+	if fv.Kind() == reflect.Ptr {
+		if val == nil {
+			return nil
+		}
+		elem := reflect.New(fv.Type().Elem())
+		if err := decodeInferredValue(elem.Elem(), field, prop, val); err != nil {
+			return err
+		}
+		fv.Set(elem)
+		return nil
+	}
+	if val != nil && reflect.TypeOf(val).AssignableTo(fv.Type()) {
+		fv.Set(reflect.ValueOf(val))
+		return nil
+	}
+	if str, ok := val.(string); ok {
+		if fv.Type() == rawMessageType {
+			fv.SetBytes([]byte(str))
+			return nil
+		}
+		if isTextUnmarshaler(fv) {
+			if err := fv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(str)); err != nil {
+				return &DecodeTypeError{Field: field.Name, Property: prop, Value: val, Type: fv.Type(), Reason: err.Error()}
+			}
+			return nil
+		}
+	}
+	return decodeScalar(fv, field, prop, inferredKind(fv.Type()), val)
+}
+
+// isTextUnmarshaler returns true if the (addressable) fv is decoded from text, e.g. a uuid.UUID
+func isTextUnmarshaler(fv reflect.Value) bool {
+	return fv.CanAddr() && reflect.PtrTo(fv.Type()).Implements(textUnmarshalerType)
+}
+
+// inferredKind returns the type option (see decodeScalar) for a field of type t without one
+func inferredKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	}
+	return "other"
+}
+
+// scalarOption returns the single-valued type option in opts, if any
+func scalarOption(opts tagOptions) string {
+	for _, kind := range []string{"string", "bool", "number", "other"} {
+		if opts.Contains(kind) {
+			return kind
+		}
+	}
+	return ""
+}
+
+// listOption returns the type of the multi-valued type option in opts (e.g. "string" for "[]string"), if any
+func listOption(opts tagOptions) string {
+	for _, kind := range []string{"string", "bool", "number", "other"} {
+		if opts.Contains("[]" + kind) {
+			return kind
+		}
+	}
+	return ""
+}
+
+// vertexProperties returns the (decoded) values of each property of vert
+func vertexProperties(vert graphson.Vertex) (map[string][]interface{}, error) {
+	props := make(map[string][]interface{}, len(vert.Value.Properties))
+	for key := range vert.Value.Properties {
+		vals, err := vertexPropertyValues(vert, key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "property %q", key)
+		}
+		props[key] = vals
+	}
+	return props, nil
+}
+
+// edgeProperties returns the (decoded) value of each property of edge
+func edgeProperties(edge graphson.Edge) (map[string][]interface{}, error) {
+	props := make(map[string][]interface{}, len(edge.Value.Properties))
+	for key, prop := range edge.Value.Properties {
+		val, err := DecodeGraphSON(prop.Value.Value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "property %q", key)
+		}
+		props[key] = []interface{}{val}
+	}
+	return props, nil
+}
+
+// vertexPropertyValues returns the (decoded) values of the property `key` of vert, ignoring any meta-properties
+func vertexPropertyValues(vert graphson.Vertex, key string) (vals []interface{}, err error) {
+	for _, prop := range vert.Value.Properties[key] {
+		if prop.Value.Label != key {
+			continue
+		}
+		var val interface{}
+		if val, err = decodeTypedInterface(prop.Value.Value); err != nil {
+			return nil, err
+		}
+		vals = append(vals, val)
+	}
+	return
+}
+
+// decodeList sets the slice fv (for `field`) to vals, each decoded as `kind`
+func decodeList(fv reflect.Value, field reflect.StructField, prop, kind string, vals []interface{}) error {
+	if fv.Kind() != reflect.Slice {
+		return &DecodeTypeError{Field: field.Name, Property: prop, Value: vals, Type: field.Type, Reason: "field with option []" + kind + " must be a slice"}
+	}
+	if len(vals) == 0 {
+		return nil
+	}
+	s := reflect.MakeSlice(fv.Type(), len(vals), len(vals))
+	for i, val := range vals {
+		if err := decodeScalar(s.Index(i), field, prop, kind, val); err != nil {
+			return err
+		}
+	}
+	fv.Set(s)
+	return nil
+}
+
+// decodeScalar sets fv (for `field`, or an element of it) to val, decoded as `kind`
+func decodeScalar(fv reflect.Value, field reflect.StructField, prop, kind string, val interface{}) error {
+	typeErr := func(reason string) error {
+		return &DecodeTypeError{Field: field.Name, Property: prop, Value: val, Type: fv.Type(), Reason: reason}
+	}
+	if isGraphUnmarshaler(fv) {
+		return errors.Wrapf(unmarshalGraph(fv, val), "property %q", prop)
+	}
+	if fv.Kind() == reflect.Ptr {
+		// as written, a nil pointer has no value
+		if val == nil {
+			return nil
+		}
+		elem := reflect.New(fv.Type().Elem())
+		if err := decodeScalar(elem.Elem(), field, prop, kind, val); err != nil {
+			return err
+		}
+		fv.Set(elem)
+		return nil
+	}
+
+	switch kind {
+	case "string":
+		str, ok := val.(string)
+		if !ok {
+			return typeErr("expected a string value")
+		}
+		if fv.Kind() != reflect.String {
+			return typeErr("field with option string must be a string")
+		}
+		fv.SetString(str)
+
+	case "bool":
+		b, ok := val.(bool)
+		if !ok {
+			return typeErr("expected a bool value")
+		}
+		if fv.Kind() != reflect.Bool {
+			return typeErr("field with option bool must be a bool")
+		}
+		fv.SetBool(b)
+
+	case "number":
+		if _, err := ToFloat64(val); err != nil {
+			return typeErr("expected a number value")
+		}
+		switch fv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			num, err := ToInt64(val)
+			if err != nil || fv.OverflowInt(num) {
+				return typeErr("number does not fit")
+			}
+			fv.SetInt(num)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			num, err := ToInt64(val)
+			if err != nil || num < 0 || fv.OverflowUint(uint64(num)) {
+				return typeErr("number does not fit")
+			}
+			fv.SetUint(uint64(num))
+		case reflect.Float32, reflect.Float64:
+			num, _ := ToFloat64(val)
+			if fv.OverflowFloat(num) {
+				return typeErr("number does not fit")
+			}
+			fv.SetFloat(num)
+		default:
+			return typeErr("field with option number must be numeric")
+		}
+
+	case "other":
+		if val == nil {
+			return nil
+		}
+		v := reflect.ValueOf(val)
+		if v.Type().AssignableTo(fv.Type()) {
+			fv.Set(v)
+		} else if v.Type().ConvertibleTo(fv.Type()) && !isLossyConversion(v, fv.Type()) {
+			fv.Set(v.Convert(fv.Type()))
+		} else {
+			return typeErr("value not assignable to field")
+		}
+
+	default:
+		return typeErr("unknown option " + kind)
+	}
+	return nil
+}
+
+// isLossyConversion returns true when converting v to t would not preserve its value
+// (e.g. a number to a string, which reflect allows as a rune conversion, or a fraction to an int)
+func isLossyConversion(v reflect.Value, t reflect.Type) bool {
+	if t.Kind() == reflect.String {
+		return v.Kind() != reflect.String
+	}
+	if v.Kind() != reflect.Float32 && v.Kind() != reflect.Float64 {
+		return false
+	}
+	if t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64 {
+		return false
+	}
+	return v.Float() != math.Trunc(v.Float())
+}
@@ -0,0 +1,350 @@
+package gremgo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/graphson"
+	"github.com/ONSdigital/gremgo-neptune/traversal"
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+)
+
+const dummyDecodeVertices = `{"@type":"g:List","@value":[` +
+	`{"@type":"g:Vertex","@value":{"id":"v1","label":"dataset",` +
+	`"properties":{` +
+	`"name":[{"@type":"g:VertexProperty","@value":{"id":{"@type":"Type","@value":1},"value":"cpih","label":"name"}}],` +
+	`"count":[{"@type":"g:VertexProperty","@value":{"id":{"@type":"Type","@value":2},"value":{"@type":"g:Int32","@value":42},"label":"count"}}],` +
+	`"size":[{"@type":"g:VertexProperty","@value":{"id":{"@type":"Type","@value":3},"value":{"@type":"g:Int64","@value":1234567890123},"label":"size"}}],` +
+	`"ratio":[{"@type":"g:VertexProperty","@value":{"id":{"@type":"Type","@value":4},"value":{"@type":"g:Double","@value":0.5},"label":"ratio"}}],` +
+	`"live":[{"@type":"g:VertexProperty","@value":{"id":{"@type":"Type","@value":5},"value":true,"label":"live"}}],` +
+	`"tags":[` +
+	`{"@type":"g:VertexProperty","@value":{"id":{"@type":"Type","@value":6},"value":"a","label":"tags"}},` +
+	`{"@type":"g:VertexProperty","@value":{"id":{"@type":"Type","@value":7},"value":"b","label":"tags"}}],` +
+	`"nums":[` +
+	`{"@type":"g:VertexProperty","@value":{"id":{"@type":"Type","@value":8},"value":{"@type":"g:Int32","@value":1},"label":"nums"}},` +
+	`{"@type":"g:VertexProperty","@value":{"id":{"@type":"Type","@value":9},"value":{"@type":"g:Int32","@value":2},"label":"nums"}}],` +
+	`"misc":[{"@type":"g:VertexProperty","@value":{"id":{"@type":"Type","@value":10},"value":"anything","label":"misc"}}]` +
+	`}}},` +
+	`{"@type":"g:Vertex","@value":{"id":"v2","label":"dataset","properties":{` +
+	`"name":[{"@type":"g:VertexProperty","@value":{"id":{"@type":"Type","@value":11},"value":"mid-year-pop-est","label":"name"}}]` +
+	`}}}` +
+	`]}`
+
+type decodeDataset struct {
+	ID     string      `graph:"id,id"`
+	Label  string      `graph:"label,label"`
+	Name   string      `graph:"name,string"`
+	Count  int32       `graph:"count,number"`
+	Size   int64       `graph:"size,number"`
+	Ratio  float64     `graph:"ratio,number"`
+	Live   bool        `graph:"live,bool"`
+	Tags   []string    `graph:"tags,[]string"`
+	Nums   []uint      `graph:"nums,[]number"`
+	Misc   interface{} `graph:"misc,other"`
+	Ignore string
+}
+
+func dummyVertices(t *testing.T, data string) []graphson.Vertex {
+	verts, err := graphson.DeserializeListOfVerticesFromBytes([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return verts
+}
+
+func TestDecodeVertices(t *testing.T) {
+	verts := dummyVertices(t, dummyDecodeVertices)
+	expected := []decodeDataset{
+		{
+			ID:    "v1",
+			Label: "dataset",
+			Name:  "cpih",
+			Count: 42,
+			Size:  1234567890123,
+			Ratio: 0.5,
+			Live:  true,
+			Tags:  []string{"a", "b"},
+			Nums:  []uint{1, 2},
+			Misc:  "anything",
+		},
+		{
+			ID:    "v2",
+			Label: "dataset",
+			Name:  "mid-year-pop-est",
+		},
+	}
+
+	var res []decodeDataset
+	if err := DecodeVertices(verts, &res); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("Expected %+v\n got %+v", expected, res)
+	}
+
+	var resPtrs []*decodeDataset
+	if err := DecodeVertices(verts, &resPtrs); err != nil {
+		t.Fatal(err)
+	}
+	if len(resPtrs) != 2 || !reflect.DeepEqual(*resPtrs[0], expected[0]) {
+		t.Errorf("Expected %+v\n got %+v", expected[0], resPtrs[0])
+	}
+
+	type withId struct {
+		Id   string
+		Name string `graph:"name,string"`
+	}
+	var single withId
+	if err := DecodeVertex(verts[1], &single); err != nil {
+		t.Fatal(err)
+	}
+	if single.Id != "v2" || single.Name != "mid-year-pop-est" {
+		t.Errorf("Expected untagged Id to be decoded, got %+v", single)
+	}
+}
+
+func TestDecodeVerticesErrors(t *testing.T) {
+	verts := dummyVertices(t, dummyDecodeVertices)
+
+	type wrongType struct {
+		Name int `graph:"name,number"`
+	}
+	type wrongField struct {
+		Name int `graph:"name,string"`
+	}
+	type multiIntoSingle struct {
+		Tags string `graph:"tags,string"`
+	}
+	type overflow struct {
+		Size int8 `graph:"size,number"`
+	}
+	type notSlice struct {
+		Tags string `graph:"tags,[]string"`
+	}
+	type fraction struct {
+		Ratio int `graph:"ratio,number"`
+	}
+
+	for _, dest := range []interface{}{
+		&[]wrongType{},
+		&[]wrongField{},
+		&[]multiIntoSingle{},
+		&[]overflow{},
+		&[]notSlice{},
+		&[]fraction{},
+	} {
+		err := DecodeVertices(verts, dest)
+		var typeErr *DecodeTypeError
+		if !errors.As(err, &typeErr) {
+			t.Errorf("Expected a DecodeTypeError for %T, got %v", dest, err)
+		}
+	}
+
+	for _, dest := range []interface{}{
+		nil,
+		[]decodeDataset{},
+		&decodeDataset{},
+		&[]string{},
+	} {
+		if err := DecodeVertices(verts, dest); err != ErrorDecodeDestination {
+			t.Errorf("Expected %v for %T, got %v", ErrorDecodeDestination, dest, err)
+		}
+	}
+}
+
+func TestGetInto(t *testing.T) {
+	errs := make(chan error)
+	p, _ := MockNewPoolWithDialerCtx(context.Background(), "ws://0", errs, t, nil, []StaggeredResponse{
+		{
+			response: Response{
+				Status: Status{Code: StatusSuccess},
+				Result: Result{Data: json.RawMessage(dummyDecodeVertices)},
+			},
+		},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var res []decodeDataset
+	if err := p.GetIntoCtx(ctx, "g.V().hasLabel('dataset')", nil, nil, &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 2 || res[0].Name != "cpih" || res[1].ID != "v2" {
+		t.Errorf("Unexpected result: %+v", res)
+	}
+}
+
+// RoundTripSource and RoundTripAudit are embedded (by value and by pointer) in roundTripDataset
+type RoundTripSource struct {
+	Source string `graph:"source"`
+}
+
+type RoundTripAudit struct {
+	By string `graph:"by"`
+}
+
+// roundTripDataset has a field of each kind written by GremlinForVertex without a type option
+type roundTripDataset struct {
+	ID        string            `graph:"id,id"`
+	Title     string            `graph:"title"`
+	Code      string            `graph:"code,key"`
+	Count     int               `graph:"count,single"`
+	Ratio     float64           `graph:"ratio"`
+	Live      bool              `graph:"live"`
+	Tags      []string          `graph:"tags,set"`
+	Released  time.Time         `graph:"released"`
+	Ref       uuid.UUID         `graph:"ref"`
+	Note      *string           `graph:"note"`
+	Missing   *int              `graph:"missing"`
+	Labels    map[string]string `graph:"labels"`
+	Scores    map[string]int    `graph:"scores"`
+	Doc       json.RawMessage   `graph:"doc"`
+	Untouched string
+	RoundTripSource
+	*RoundTripAudit
+}
+
+// roundTripEdge has a field of each kind written by GremlinForEdge without a type option
+type roundTripEdge struct {
+	ID      string            `graph:"id,id"`
+	From    string            `graph:"from,from"`
+	To      string            `graph:"to,to"`
+	Weight  float64           `graph:"weight"`
+	Since   time.Time         `graph:"since,single"`
+	Note    *string           `graph:"note"`
+	Missing *int              `graph:"missing"`
+	Labels  map[string]string `graph:"labels"`
+	RoundTripSource
+}
+
+// storedProperties returns the properties written for data (as by GremlinForVertex and GremlinForEdge),
+// each value as GraphSON, as they are stored in the graph
+func storedProperties(t *testing.T, data interface{}) (sv structElement, props map[string][]json.RawMessage) {
+	sv, err := readStruct(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	props = make(map[string][]json.RawMessage)
+	for _, prop := range sv.props {
+		for _, val := range prop.values {
+			gval, err := traversal.GraphSONValue(val)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := json.Marshal(gval)
+			if err != nil {
+				t.Fatal(err)
+			}
+			props[prop.name] = append(props[prop.name], b)
+		}
+	}
+	return
+}
+
+// storedVertex returns the vertex written for data, as read back from the graph
+func storedVertex(t *testing.T, data interface{}) graphson.Vertex {
+	sv, props := storedProperties(t, data)
+	vertProps := make(map[string][]json.RawMessage)
+	id := 0
+	for name, vals := range props {
+		for _, val := range vals {
+			id++
+			vertProps[name] = append(vertProps[name], json.RawMessage(fmt.Sprintf(
+				`{"@type":"g:VertexProperty","@value":{"id":{"@type":"g:Int32","@value":%d},"value":%s,"label":%q}}`, id, val, name)))
+		}
+	}
+	b, err := json.Marshal(vertProps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dummyVertices(t, fmt.Sprintf(`{"@type":"g:List","@value":[{"@type":"g:Vertex","@value":{"id":%q,"label":"dataset","properties":%s}}]}`,
+		sv.id, b))[0]
+}
+
+// storedEdge returns the edge written for data, as read back from the graph
+func storedEdge(t *testing.T, data interface{}) graphson.Edge {
+	sv, props := storedProperties(t, data)
+	edgeProps := make(map[string]json.RawMessage)
+	for name, vals := range props {
+		edgeProps[name] = json.RawMessage(fmt.Sprintf(`{"@type":"g:Property","@value":{"key":%q,"value":%s}}`, name, vals[0]))
+	}
+	b, err := json.Marshal(edgeProps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edges, err := graphson.DeserializeListOfEdgesFromBytes([]byte(fmt.Sprintf(`{"@type":"g:List","@value":[{"@type":"g:Edge","@value":`+
+		`{"id":%q,"label":"follows","inVLabel":"dataset","outVLabel":"dataset","inV":%q,"outV":%q,"properties":%s}}]}`,
+		sv.id, sv.to, sv.from, b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return edges[0]
+}
+
+func TestDecodeVertexRoundTrip(t *testing.T) {
+	note := "a note"
+	written := roundTripDataset{
+		ID:              "v1",
+		Title:           "CPIH",
+		Code:            "cpih01",
+		Count:           42,
+		Ratio:           0.5,
+		Live:            true,
+		Tags:            []string{"a", "b"},
+		Released:        time.Date(2020, 1, 2, 3, 4, 5, 6000000, time.UTC),
+		Ref:             uuid.Must(uuid.FromString("6ba7b810-9dad-11d1-80b4-00c04fd430c8")),
+		Note:            &note,
+		Labels:          map[string]string{"en": "Consumer prices", "cy": "Prisiau"},
+		Scores:          map[string]int{"x": 1, "y": 2},
+		Doc:             json.RawMessage(`{"a":1}`),
+		Untouched:       "not written",
+		RoundTripSource: RoundTripSource{Source: "ons"},
+		RoundTripAudit:  &RoundTripAudit{By: "someone"},
+	}
+
+	var read roundTripDataset
+	if err := DecodeVertex(storedVertex(t, written), &read); err != nil {
+		t.Fatal(err)
+	}
+	written.Untouched = ""
+	if !reflect.DeepEqual(read, written) {
+		t.Errorf("Expected the vertex to read back as written\nexpected %+v\ngot      %+v", written, read)
+	}
+
+	// nil pointers (including embedded) and empty maps are not written, so are read back as nil
+	written = roundTripDataset{ID: "v2", Title: "empty"}
+	read = roundTripDataset{}
+	if err := DecodeVertex(storedVertex(t, written), &read); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read, written) {
+		t.Errorf("Expected the vertex to read back as written\nexpected %+v\ngot      %+v", written, read)
+	}
+}
+
+func TestDecodeEdgeRoundTrip(t *testing.T) {
+	note := "a note"
+	written := roundTripEdge{
+		ID:              "e1",
+		From:            "v1",
+		To:              "v2",
+		Weight:          1.5,
+		Since:           time.Date(2021, 6, 7, 8, 9, 10, 0, time.UTC),
+		Note:            &note,
+		Labels:          map[string]string{"en": "follows"},
+		RoundTripSource: RoundTripSource{Source: "ons"},
+	}
+
+	var read roundTripEdge
+	if err := DecodeEdge(storedEdge(t, written), &read); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read, written) {
+		t.Errorf("Expected the edge to read back as written\nexpected %+v\ngot      %+v", written, read)
+	}
+}
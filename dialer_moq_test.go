@@ -47,7 +47,7 @@ var _ dialer = &dialerMock{}
 //             connectCtxFunc: func(in1 context.Context) error {
 // 	               panic("mock out the connectCtx method")
 //             },
-//             getAuthFunc: func() *auth {
+//             getAuthFunc: func() (*auth, error) {
 // 	               panic("mock out the getAuth method")
 //             },
 //             pingFunc: func(errs chan error)  {
@@ -88,7 +88,7 @@ type dialerMock struct {
 	connectCtxFunc func(in1 context.Context) error
 
 	// getAuthFunc mocks the getAuth method.
-	getAuthFunc func() *auth
+	getAuthFunc func() (*auth, error)
 
 	// pingFunc mocks the ping method.
 	pingFunc func(errs chan error)
@@ -293,7 +293,7 @@ func (mock *dialerMock) connectCtxCalls() []struct {
 }
 
 // getAuth calls getAuthFunc.
-func (mock *dialerMock) getAuth() *auth {
+func (mock *dialerMock) getAuth() (*auth, error) {
 	if mock.getAuthFunc == nil {
 		panic("dialerMock.getAuthFunc: method is nil but dialer.getAuth was just called")
 	}
@@ -0,0 +1,123 @@
+package gremgo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ONSdigital/graphson"
+	"github.com/ONSdigital/gremgo-neptune/traversal"
+	"github.com/pkg/errors"
+)
+
+var ErrorEdgeVertices = errors.New("edge needs the ids of its vertices, in fields tagged with the from and to options")
+
+// GremlinForEdge returns the addE() gremlin command for `data`, a struct with `graph` tags for its outgoing and
+// incoming vertex ids (options `from` and `to`), optional id and label, and (single-valued) properties, e.g.
+//
+//	type HasEdition struct {
+//		Dataset string `graph:"dataset,from"`
+//		Edition string `graph:"edition,to"`
+//		Since   int64  `graph:"since,number"`
+//	}
+//
+// The label is taken from the field with the `label` option, unless given.
+// Unlike GremlinForVertex, the command starts with `g.`
+func GremlinForEdge(label string, data interface{}) (gremAdd string, err error) {
+	var t *traversal.Traversal
+	if t, err = edgeTraversal(traversal.G.AddE, label, data); err != nil {
+		return
+	}
+	return t.Groovy()
+}
+
+// edgeTraversal returns the traversal which adds the edge for data (see GremlinForEdge), started with addE
+// (e.g. traversal.G.AddE, or traversal.Anon().AddE for a child traversal)
+func edgeTraversal(addE func(label string) *traversal.Traversal, label string, data interface{}) (*traversal.Traversal, error) {
+	se, err := readStruct(data)
+	if err != nil {
+		return nil, err
+	}
+	if label == "" {
+		label = se.label
+	}
+	if se.from == nil || se.to == nil {
+		return nil, ErrorEdgeVertices
+	}
+
+	t := addE(label).From(traversal.Anon().V(se.from)).To(traversal.Anon().V(se.to))
+	if se.id != nil {
+		t = t.Property(traversal.TId, se.id)
+	}
+	for _, prop := range se.props {
+		if prop.list {
+			return nil, fmt.Errorf("interface field tag %q has a slice option, but edge properties are single-valued", prop.name)
+		}
+		if prop.cardinality != "" {
+			return nil, fmt.Errorf("interface field tag %q has option %s, but edge properties have no cardinality", prop.name, prop.cardinality)
+		}
+		for _, val := range prop.values {
+			t = t.Property(prop.name, val)
+		}
+	}
+	return t, t.Err()
+}
+
+// AddEdgeFromStruct adds the edge for data (see GremlinForEdge) to the graph, returning the new edge
+func (c *Client) AddEdgeFromStruct(label string, data interface{}) (edge graphson.Edge, err error) {
+	return c.AddEdgeFromStructCtx(context.Background(), label, data)
+}
+func (c *Client) AddEdgeFromStructCtx(ctx context.Context, label string, data interface{}) (edge graphson.Edge, err error) {
+	if c.conn.IsDisposed() {
+		return edge, ErrorConnectionDisposed
+	}
+
+	var q string
+	if q, err = GremlinForEdge(label, data); err != nil {
+		return
+	}
+	var resp []Response
+	if resp, err = c.ExecuteCtx(ctx, q, nil, nil); err != nil {
+		return
+	}
+	var edges []graphson.Edge
+	if edges, err = c.deserializeResponseToEdges(resp); err != nil {
+		return
+	}
+	if len(edges) != 1 {
+		return edge, fmt.Errorf("AddEdgeFromStruct should receive 1 edge, got %d", len(edges))
+	}
+	return edges[0], nil
+}
+
+// GetEdgesIntoCtx executes a query which returns edges, and decodes them into dest
+// (a pointer to a slice of `graph`-tagged structs, see DecodeEdges)
+func (c *Client) GetEdgesIntoCtx(ctx context.Context, query string, bindings, rebindings map[string]string, dest interface{}) (err error) {
+	var res []graphson.Edge
+	if res, err = c.GetEdgeCtx(ctx, query, bindings, rebindings); err != nil {
+		return
+	}
+	return DecodeEdges(res, dest)
+}
+
+// AddEdgeFromStruct adds the edge for data (see GremlinForEdge) to the graph, returning the new edge
+func (p *Pool) AddEdgeFromStruct(label string, data interface{}) (edge graphson.Edge, err error) {
+	return p.AddEdgeFromStructCtx(context.Background(), label, data)
+}
+func (p *Pool) AddEdgeFromStructCtx(ctx context.Context, label string, data interface{}) (edge graphson.Edge, err error) {
+	var pc *conn
+	if pc, err = p.connCtx(ctx); err != nil {
+		return edge, errors.Wrap(err, "AddEdgeFromStructCtx: Failed p.connCtx")
+	}
+	defer p.putConn(pc, err)
+	return pc.Client.AddEdgeFromStructCtx(ctx, label, data)
+}
+
+// GetEdgesIntoCtx executes a query which returns edges, and decodes them into dest (see DecodeEdges)
+func (p *Pool) GetEdgesIntoCtx(ctx context.Context, query string, bindings, rebindings map[string]string, dest interface{}) (err error) {
+	var pc *conn
+	if pc, err = p.connCtx(ctx); err != nil {
+		return errors.Wrap(err, "GetEdgesIntoCtx: Failed p.connCtx")
+	}
+	defer p.putConn(pc, err)
+	return pc.Client.GetEdgesIntoCtx(ctx, query, bindings, rebindings, dest)
+}
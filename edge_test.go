@@ -0,0 +1,146 @@
+package gremgo
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/graphson"
+)
+
+type hasEdition struct {
+	Id      string
+	Label   string  `graph:"label,label"`
+	Dataset string  `graph:"dataset,from"`
+	Edition string  `graph:"edition,to"`
+	Since   int64   `graph:"since,number"`
+	Note    string  `graph:"note,string"`
+	Score   float64 `graph:"score,number"`
+}
+
+const dummyEdges = `{"@type":"g:List","@value":[{"@type":"g:Edge","@value":{"id":"e1","label":"hasEdition","inVLabel":"edition","outVLabel":"dataset","inV":"cpih-2020","outV":"cpih",` +
+	`"properties":{"since":{"@type":"g:Property","@value":{"key":"since","value":{"@type":"g:Int64","@value":1600000000000}}},` +
+	`"note":{"@type":"g:Property","@value":{"key":"note","value":"it's new"}}}}}]}`
+
+func TestGremlinForEdge(t *testing.T) {
+	type listProps struct {
+		From string   `graph:"from,from"`
+		To   string   `graph:"to,to"`
+		Tags []string `graph:"tags,[]string"`
+	}
+	type cardinalityProps struct {
+		From string `graph:"from,from"`
+		To   string `graph:"to,to"`
+		Note string `graph:"note,string,single"`
+	}
+	testData := []struct {
+		label    string
+		data     interface{}
+		expected string
+	}{
+		{
+			"hasEdition",
+			hasEdition{Dataset: "cpih", Edition: "cpih-2020", Since: 1, Note: "it's"},
+			`g.addE('hasEdition').from(__.V('cpih')).to(__.V('cpih-2020')).property('since',1).property('note','it\'s').property('score',0d)`,
+		},
+		{
+			"",
+			&hasEdition{Id: "e1", Label: "fromField", Dataset: "a", Edition: "b"},
+			`g.addE('fromField').from(__.V('a')).to(__.V('b')).property(T.id,'e1').property('since',0).property('score',0d)`,
+		},
+	}
+	for _, td := range testData {
+		q, err := GremlinForEdge(td.label, td.data)
+		if err != nil {
+			t.Errorf("%+v: %v", td.data, err)
+		} else if q != td.expected {
+			t.Errorf("%+v: expected\n%s\ngot\n%s", td.data, td.expected, q)
+		}
+	}
+
+	if _, err := GremlinForEdge("e", hasEdition{Dataset: "a"}); err != ErrorEdgeVertices {
+		t.Errorf("Expected %v, got %v", ErrorEdgeVertices, err)
+	}
+	if _, err := GremlinForEdge("e", listProps{From: "a", To: "b", Tags: []string{"x"}}); err == nil {
+		t.Error("Expected an error for a slice option")
+	}
+	if _, err := GremlinForEdge("e", cardinalityProps{From: "a", To: "b", Note: "x"}); err == nil {
+		t.Error("Expected an error for a cardinality option")
+	}
+	if _, err := GremlinForEdge("e", "x"); err != ErrorNotStruct {
+		t.Errorf("Expected %v, got %v", ErrorNotStruct, err)
+	}
+}
+
+func TestDecodeEdges(t *testing.T) {
+	edges, err := graphson.DeserializeListOfEdgesFromBytes([]byte(dummyEdges))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := hasEdition{Id: "e1", Label: "hasEdition", Dataset: "cpih", Edition: "cpih-2020", Since: 1600000000000, Note: "it's new"}
+
+	var res []*hasEdition
+	if err = DecodeEdges(edges, &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 || !reflect.DeepEqual(*res[0], expected) {
+		t.Errorf("Expected %+v, got %+v", expected, res)
+	}
+
+	var single hasEdition
+	if err = DecodeEdge(edges[0], &single); err != nil || !reflect.DeepEqual(single, expected) {
+		t.Errorf("Expected %+v, got %+v %v", expected, single, err)
+	}
+	if err = DecodeEdge(edges[0], single); err != ErrorDecodeDestinationItem {
+		t.Errorf("Expected %v, got %v", ErrorDecodeDestinationItem, err)
+	}
+
+	var wrongType []struct {
+		Since string `graph:"since,string"`
+	}
+	if err = DecodeEdges(edges, &wrongType); err == nil {
+		t.Error("Expected a type error")
+	}
+}
+
+func TestAddEdgeFromStructCtx(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c := newChunksClient()
+	sent := make(chan request, 2)
+	go func() {
+		for i := 0; i < 2; i++ {
+			msg := <-c.requests
+			var req request
+			if err := json.Unmarshal(msg[len(mimeTypePrefix):], &req); err != nil {
+				t.Error(err)
+			}
+			sent <- req
+			c.saveResponse(Response{RequestID: req.RequestID, Status: Status{Code: StatusSuccess}, Result: Result{Data: json.RawMessage(dummyEdges)}}, nil)
+		}
+	}()
+
+	edge, err := c.AddEdgeFromStructCtx(ctx, "hasEdition", hasEdition{Dataset: "cpih", Edition: "cpih-2020"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edge.Value.ID != "e1" || edge.Value.OutV != "cpih" {
+		t.Errorf("Expected the new edge, got %+v", edge)
+	}
+	if req := <-sent; req.Args["gremlin"] != `g.addE('hasEdition').from(__.V('cpih')).to(__.V('cpih-2020')).property('since',0).property('score',0d)` {
+		t.Errorf("Unexpected query %s", req.Args["gremlin"])
+	}
+
+	var res []hasEdition
+	if err = c.GetEdgesIntoCtx(ctx, "g.E('e1')", nil, nil, &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 || res[0].Note != "it's new" || res[0].Edition != "cpih-2020" {
+		t.Errorf("Expected the decoded edge, got %+v", res)
+	}
+	<-sent
+	assertNoRequestState(t, c)
+}
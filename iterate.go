@@ -0,0 +1,67 @@
+package gremgo
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// Item is a single result from Iterate, or the error which ended the iteration
+type Item struct {
+	Value interface{}
+	Err   error
+}
+
+// Iterate executes a query, returning a channel which is fed each result (decoded as by DecodeGraphSON)
+// as the responses arrive. The channel is closed at the end of the results, after an Item with an error,
+// or when ctx is done (in which case the rest of the results are discarded).
+// A slow receiver is subject to the cursor buffer policy (see SetCursorBufferPolicy).
+func (c *Client) Iterate(ctx context.Context, query string, bindings, rebindings map[string]string) <-chan Item {
+	vc, err := c.OpenValueCursorCtx(ctx, query, bindings, rebindings)
+	return iterate(ctx, vc, errors.Wrap(err, "Iterate"))
+}
+
+// Iterate executes a query, returning a channel which is fed each result as the responses arrive (see Client.Iterate)
+func (p *Pool) Iterate(ctx context.Context, query string, bindings, rebindings map[string]string) <-chan Item {
+	vc, err := p.OpenValueCursorCtx(ctx, query, bindings, rebindings)
+	return iterate(ctx, vc, errors.Wrap(err, "Iterate"))
+}
+
+// iterate feeds the results of vc into the returned channel (or just openErr, when the cursor failed to open)
+func iterate(ctx context.Context, vc *ValueCursor, openErr error) <-chan Item {
+	items := make(chan Item)
+	go func() {
+		defer close(items)
+		send := func(item Item) bool {
+			select {
+			case items <- item:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		if openErr != nil {
+			send(Item{Err: openErr})
+			return
+		}
+		defer vc.Close(ctx)
+
+		for eof := false; !eof; {
+			var vals List
+			var err error
+			if vals, eof, err = vc.NextValues(ctx); err != nil {
+				if ctx.Err() == nil {
+					send(Item{Err: err})
+				}
+				return
+			}
+			for _, val := range vals {
+				if !send(Item{Value: val}) {
+					return
+				}
+			}
+		}
+	}()
+	return items
+}
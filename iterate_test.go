@@ -0,0 +1,122 @@
+package gremgo
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestIterate(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Run("all results, fanned out to workers", func(t *testing.T) {
+		c := newChunksClient()
+		serveChunks(t, c, 20, 10, Status{Code: StatusNoContent}, nil)
+		items := c.Iterate(ctx, "g.V().values('name')", nil, nil)
+
+		var count int64
+		var wg sync.WaitGroup
+		for w := 0; w < 4; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for item := range items {
+					if item.Err != nil {
+						t.Error(item.Err)
+						continue
+					}
+					if _, ok := item.Value.(string); !ok {
+						t.Errorf("Expected a string, got %T", item.Value)
+					}
+					atomic.AddInt64(&count, 1)
+				}
+			}()
+		}
+		wg.Wait()
+		if count != 200 {
+			t.Errorf("Expected 200 items, got %d", count)
+		}
+		assertNoRequestState(t, c)
+	})
+
+	t.Run("server error ends the iteration", func(t *testing.T) {
+		c := newChunksClient()
+		serverErr := &ServerError{Code: StatusServerError, Message: "BOOM"}
+		serveChunks(t, c, 2, 10, Status{Code: StatusServerError, Message: "BOOM"}, serverErr)
+		var values int
+		var lastErr error
+		for item := range c.Iterate(ctx, "g.V().values('name')", nil, nil) {
+			if item.Err != nil {
+				lastErr = item.Err
+				continue
+			}
+			values++
+		}
+		if values != 20 || !errors.Is(lastErr, ErrServerError) {
+			t.Errorf("Expected 20 values then %v, got %d then %v", ErrServerError, values, lastErr)
+		}
+	})
+
+	t.Run("cancellation closes the channel and discards the rest", func(t *testing.T) {
+		c := newChunksClient()
+		serveChunks(t, c, 50, 10, Status{Code: StatusNoContent}, nil)
+		iterCtx, iterCancel := context.WithCancel(ctx)
+		items := c.Iterate(iterCtx, "g.V().values('name')", nil, nil)
+		<-items
+		iterCancel()
+
+		closed := make(chan struct{})
+		go func() {
+			for range items {
+			}
+			close(closed)
+		}()
+		select {
+		case <-closed:
+		case <-time.After(time.Second):
+			t.Fatal("Expected the channel to be closed after cancellation")
+		}
+		time.Sleep(50 * time.Millisecond)
+		assertNoRequestState(t, c)
+	})
+
+	t.Run("disposed connection", func(t *testing.T) {
+		c := newClient()
+		c.conn = &dialerMock{IsDisposedFunc: func() bool { return true }}
+		items := c.Iterate(ctx, "g.V()", nil, nil)
+		if item := <-items; errors.Cause(item.Err) != ErrorConnectionDisposed {
+			t.Errorf("Expected %v, got %v", ErrorConnectionDisposed, item.Err)
+		}
+		if _, ok := <-items; ok {
+			t.Error("Expected the channel to be closed")
+		}
+	})
+}
+
+func TestPoolIterate(t *testing.T) {
+	errs := make(chan error)
+	p, _ := MockNewPoolWithDialerCtx(context.Background(), "ws://0", errs, t, nil, []StaggeredResponse{
+		{response: Response{Status: Status{Code: StatusPartialContent}, Result: Result{Data: json.RawMessage(dummyElementMaps)}}},
+		{after: 50 * time.Millisecond, response: Response{Status: Status{Code: StatusSuccess}, Result: Result{Data: json.RawMessage(dummyEdgeElementMaps)}}},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var labels []string
+	for item := range p.Iterate(ctx, "g.V().elementMap()", nil, nil) {
+		if item.Err != nil {
+			t.Fatal(item.Err)
+		}
+		label, _ := item.Value.(Map).GetString(TLabel)
+		labels = append(labels, label)
+	}
+	if len(labels) != 2 || labels[0] != "dataset" || labels[1] != "hasEdition" {
+		t.Errorf("Unexpected results %v", labels)
+	}
+}
@@ -0,0 +1,144 @@
+package gremgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ResponseLimit caps the total size of the responses to a request, zero values are unlimited
+type ResponseLimit struct {
+	MaxBytes int64 // the total size of the result data of the responses
+	MaxItems int64 // the total number of results (items in the result lists) of the responses
+}
+
+// CancelQueryFunc cancels the query for requestID on the server (e.g. NeptuneCancelQuery)
+type CancelQueryFunc func(ctx context.Context, requestID string) error
+
+// ResponseTooLargeError is the error for a request whose responses exceeded its ResponseLimit
+type ResponseTooLargeError struct {
+	RequestID string
+	Unit      string // "bytes" or "items"
+	Limit     int64
+	Size      int64 // the size when the limit was exceeded
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("%s: request %s exceeded limit of %d %s (got %d)", ErrorResponseTooLarge, e.RequestID, e.Limit, e.Unit, e.Size)
+}
+
+// Is returns true for ErrorResponseTooLarge
+func (e *ResponseTooLargeError) Is(target error) bool {
+	return target == ErrorResponseTooLarge
+}
+
+type responseLimitKey struct{}
+
+// WithResponseLimit returns a context which applies limit to requests made with it,
+// in place of the client's limit (see SetResponseLimit)
+func WithResponseLimit(ctx context.Context, limit ResponseLimit) context.Context {
+	return context.WithValue(ctx, responseLimitKey{}, limit)
+}
+
+// responseSize is the running total size of the responses to a request which has a limit
+type responseSize struct {
+	limit ResponseLimit
+	bytes int64
+	items int64
+}
+
+// trackResponseSize records the limit (if any) on the size of the responses to the request
+func (c *Client) trackResponseSize(ctx context.Context, id string) {
+	limit := c.responseLimit
+	if ctxLimit, ok := ctx.Value(responseLimitKey{}).(ResponseLimit); ok {
+		limit = ctxLimit
+	}
+	if limit.MaxBytes > 0 || limit.MaxItems > 0 {
+		c.responseSizes.Store(id, &responseSize{limit: limit})
+	}
+}
+
+// addResponseSize adds resp to the total size of the responses to its request,
+// returning a *ResponseTooLargeError when that exceeds the request's limit (must be locked)
+func (c *Client) addResponseSize(resp Response) error {
+	sizeI, ok := c.responseSizes.Load(resp.RequestID)
+	if !ok {
+		return nil
+	}
+	size := sizeI.(*responseSize)
+	size.bytes += int64(len(resp.Result.Data))
+	if size.limit.MaxBytes > 0 && size.bytes > size.limit.MaxBytes {
+		return &ResponseTooLargeError{RequestID: resp.RequestID, Unit: "bytes", Limit: size.limit.MaxBytes, Size: size.bytes}
+	}
+	if size.limit.MaxItems > 0 {
+		size.items += countResultItems(resp.Result.Data)
+		if size.items > size.limit.MaxItems {
+			return &ResponseTooLargeError{RequestID: resp.RequestID, Unit: "items", Limit: size.limit.MaxItems, Size: size.items}
+		}
+	}
+	return nil
+}
+
+// countResultItems returns the number of items in the g:List of result data (or 1 for any other value)
+func countResultItems(data json.RawMessage) (n int64) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return 0
+	}
+	var list struct {
+		Type  string            `json:"@type"`
+		Value []json.RawMessage `json:"@value"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil || list.Type != "g:List" {
+		return 1
+	}
+	return int64(len(list.Value))
+}
+
+// abortTooLarge fails the request whose responses are too large, and cancels its query on the server
+// (when the client has a CancelQueryFunc)
+func (c *Client) abortTooLarge(id string, err error) {
+	c.failRequest(id, err)
+	if c.cancelQuery == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := c.withResponseTimeout(context.Background())
+		defer cancel()
+		if err := c.cancelQuery(ctx, id); err != nil {
+			log.Println(errors.Wrapf(err, "cancel query %s", id))
+		}
+	}()
+}
+
+// NeptuneCancelQuery returns a CancelQueryFunc which uses the Neptune Gremlin query status API,
+// e.g. `https://your-neptune-endpoint:8182/gremlin/status`, to cancel queries
+// (for which Neptune uses the request ID as the query ID). A nil httpClient uses http.DefaultClient.
+func NeptuneCancelQuery(statusURL string, httpClient *http.Client) CancelQueryFunc {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return func(ctx context.Context, requestID string) error {
+		form := url.Values{"cancelQuery": {""}, "queryId": {requestID}}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, statusURL, strings.NewReader(form.Encode()))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return errors.Errorf("cancel query %s: unexpected status %s", requestID, resp.Status)
+		}
+		return nil
+	}
+}
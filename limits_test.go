@@ -0,0 +1,103 @@
+package gremgo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestResponseLimit(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Run("within the limit", func(t *testing.T) {
+		c := newChunksClient()
+		SetResponseLimit(ResponseLimit{MaxBytes: 1 << 20, MaxItems: 100})(c)
+		serveChunks(t, c, 10, 10, Status{Code: StatusNoContent}, nil)
+		res, err := c.QueryCtx(ctx, "g.V().values('name')", nil, nil)
+		if err != nil || len(res) != 100 {
+			t.Errorf("Expected 100 results, got %d %v", len(res), err)
+		}
+		assertNoRequestState(t, c)
+	})
+
+	t.Run("client byte limit", func(t *testing.T) {
+		c := newChunksClient()
+		SetResponseLimit(ResponseLimit{MaxBytes: 2000})(c)
+		serveChunks(t, c, 10, 10, Status{Code: StatusNoContent}, nil)
+		_, err := c.QueryCtx(ctx, "g.V().values('name')", nil, nil)
+		var tooLarge *ResponseTooLargeError
+		if !errors.Is(err, ErrorResponseTooLarge) || !errors.As(err, &tooLarge) {
+			t.Fatalf("Expected %v, got %v", ErrorResponseTooLarge, err)
+		}
+		if tooLarge.Unit != "bytes" || tooLarge.Limit != 2000 || tooLarge.Size <= 2000 {
+			t.Errorf("Unexpected error details %+v", tooLarge)
+		}
+		time.Sleep(50 * time.Millisecond)
+		assertNoRequestState(t, c)
+	})
+
+	t.Run("request item limit overrides the client limit", func(t *testing.T) {
+		c := newChunksClient()
+		SetResponseLimit(ResponseLimit{MaxItems: 1000})(c)
+		cancelled := make(chan string, 1)
+		SetCancelQuery(func(ctx context.Context, requestID string) error {
+			cancelled <- requestID
+			return nil
+		})(c)
+		serveChunks(t, c, 10, 10, Status{Code: StatusNoContent}, nil)
+
+		var items int
+		err := c.QueryChunksCtx(WithResponseLimit(ctx, ResponseLimit{MaxItems: 25}), "g.V().values('name')", nil, nil, func(chunk List) error {
+			items += len(chunk)
+			return nil
+		})
+		var tooLarge *ResponseTooLargeError
+		if !errors.As(err, &tooLarge) || tooLarge.Unit != "items" || tooLarge.Size != 30 {
+			t.Fatalf("Expected items limit error, got %v", err)
+		}
+		if items > 20 {
+			t.Errorf("Expected at most the 20 items within the limit, got %d", items)
+		}
+		select {
+		case id := <-cancelled:
+			if id != tooLarge.RequestID {
+				t.Errorf("Expected query %s to be cancelled, got %s", tooLarge.RequestID, id)
+			}
+		case <-time.After(time.Second):
+			t.Error("Expected the query to be cancelled on the server")
+		}
+		time.Sleep(50 * time.Millisecond)
+		assertNoRequestState(t, c)
+	})
+}
+
+func TestNeptuneCancelQuery(t *testing.T) {
+	var gotMethod, gotCancel, gotQueryID string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		r.ParseForm()
+		_, hasCancel := r.PostForm["cancelQuery"]
+		gotCancel = map[bool]string{true: "yes"}[hasCancel]
+		gotQueryID = r.PostForm.Get("queryId")
+		if gotQueryID == "unknown" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	cancelQuery := NeptuneCancelQuery(server.URL+"/gremlin/status", nil)
+	if err := cancelQuery(context.Background(), "req-1"); err != nil {
+		t.Fatal(err)
+	}
+	if gotMethod != http.MethodPost || gotCancel != "yes" || gotQueryID != "req-1" {
+		t.Errorf("Unexpected cancel request: %s cancelQuery=%q queryId=%q", gotMethod, gotCancel, gotQueryID)
+	}
+	if err := cancelQuery(context.Background(), "unknown"); err == nil {
+		t.Error("Expected an error for a failed cancel")
+	}
+}
@@ -0,0 +1,44 @@
+package gremgo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ONSdigital/gremgo-neptune/lint"
+)
+
+// LintError is the error for a query which was not sent, because it uses features Neptune does not support
+// (see SetLint)
+type LintError struct {
+	Query    string
+	Findings []lint.Finding
+}
+
+func (e *LintError) Error() string {
+	msgs := make([]string, len(e.Findings))
+	for i, f := range e.Findings {
+		msgs[i] = f.String()
+	}
+	return fmt.Sprintf("%s: %s (query: %s)", ErrorLint, strings.Join(msgs, "; "), e.Query)
+}
+
+// Is returns true for ErrorLint
+func (e *LintError) Is(target error) bool {
+	return target == ErrorLint
+}
+
+// lintQuery returns a *LintError if the client lints queries and query has any findings,
+// allowing the names of any bindings sent to the server as variables
+func (c *Client) lintQuery(query string, bindings Bindings) error {
+	if !c.lint {
+		return nil
+	}
+	names := make([]string, 0, len(bindings))
+	for name := range bindings {
+		names = append(names, name)
+	}
+	if findings := lint.Lint(query, names...); len(findings) > 0 {
+		return &LintError{Query: query, Findings: findings}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package lint
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenPunct
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	offset int
+	// interpolated is set for a double-quoted (GString) string containing `$`
+	interpolated bool
+}
+
+// operators are the multi-character operators, longest first
+var operators = []string{"===", "!==", "<=>", "==", "!=", "<=", ">=", "&&", "||", "->", "::", "?.", "*.", "..", "++", "--", "+=", "-=", "*=", "/="}
+
+// lex splits script into tokens (skipping whitespace and comments), reporting any syntax errors as findings
+func lex(script string) (tokens []token, findings []Finding) {
+	for i := 0; i < len(script); {
+		r, size := utf8.DecodeRuneInString(script[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case strings.HasPrefix(script[i:], "//"):
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				end = len(script) - i
+			}
+			i += end
+		case strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				findings = append(findings, newFinding(script, i, RuleSyntax, "unterminated comment"))
+				i = len(script)
+			} else {
+				i += end + 4
+			}
+		case r == '\'' || r == '"':
+			end, ok := stringEnd(script, i)
+			if !ok {
+				findings = append(findings, newFinding(script, i, RuleSyntax, "unterminated string"))
+			}
+			text := script[i:end]
+			tokens = append(tokens, token{kind: tokenString, text: text, offset: i, interpolated: r == '"' && strings.Contains(text, "$")})
+			i = end
+		case r == '_' || r == '$' || unicode.IsLetter(r):
+			end := i + size
+			for end < len(script) {
+				r, size := utf8.DecodeRuneInString(script[end:])
+				if r != '_' && r != '$' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				end += size
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: script[i:end], offset: i})
+			i = end
+		case r >= '0' && r <= '9':
+			end := i + 1
+			for end < len(script) && (isNumberPart(script[end]) || (script[end] == '.' && end+1 < len(script) && script[end+1] >= '0' && script[end+1] <= '9')) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: script[i:end], offset: i})
+			i = end
+		default:
+			text := script[i : i+size]
+			for _, op := range operators {
+				if strings.HasPrefix(script[i:], op) {
+					text = op
+					break
+				}
+			}
+			tokens = append(tokens, token{kind: tokenPunct, text: text, offset: i})
+			i += len(text)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, offset: len(script)}), findings
+}
+
+func isNumberPart(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_'
+}
+
+// stringEnd returns the index after the (single, double or triple-quoted) string starting at start,
+// and whether it is terminated
+func stringEnd(script string, start int) (int, bool) {
+	quote := script[start : start+1]
+	if strings.HasPrefix(script[start:], strings.Repeat(quote, 3)) {
+		quote = strings.Repeat(quote, 3)
+	}
+	for i := start + len(quote); i < len(script); i++ {
+		switch {
+		case script[i] == '\\':
+			i++
+		case strings.HasPrefix(script[i:], quote):
+			return i + len(quote), true
+		case script[i] == '\n' && len(quote) == 1:
+			return i, false
+		}
+	}
+	return len(script), false
+}
@@ -0,0 +1,362 @@
+// Package lint checks Gremlin-Groovy scripts for features which Amazon Neptune does not support (see
+// https://docs.aws.amazon.com/neptune/latest/userguide/access-graph-gremlin-differences.html), such as lambdas,
+// variables, Java class access and OLAP steps, reporting each finding with its position in the script.
+//
+//	for _, f := range lint.Lint("g.V().map{ it.get() }") {
+//		fmt.Println(f) // 1:12: lambda: lambdas (closures) are not supported
+//	}
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Rule identifies the kind of a Finding
+type Rule string
+
+const (
+	RuleSyntax        Rule = "syntax"
+	RuleStart         Rule = "start"
+	RuleLambda        Rule = "lambda"
+	RuleVariable      Rule = "variable"
+	RuleClass         Rule = "class"
+	RuleFunction      Rule = "function"
+	RuleStep          Rule = "step"
+	RuleInterpolation Rule = "interpolation"
+)
+
+// Position is a position in a script: the byte offset, and the line and column (in characters), from 1
+type Position struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// Finding is a feature of a script which Neptune does not support
+type Finding struct {
+	Pos     Position
+	Rule    Rule
+	Message string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s: %s", f.Pos, f.Rule, f.Message)
+}
+
+func newFinding(script string, offset int, rule Rule, format string, args ...interface{}) Finding {
+	before := script[:offset]
+	line := strings.Count(before, "\n") + 1
+	column := utf8.RuneCountInString(before[strings.LastIndexByte(before, '\n')+1:]) + 1
+	return Finding{
+		Pos:     Position{Offset: offset, Line: line, Column: column},
+		Rule:    rule,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// unsupportedSteps are the steps which Neptune does not support (chiefly those requiring a GraphComputer)
+var unsupportedSteps = map[string]string{
+	"program":            "the program() step is not supported",
+	"io":                 "the io() step is not supported (use the Neptune bulk loader)",
+	"subgraph":           "the subgraph() step is not supported",
+	"withComputer":       "OLAP (withComputer()) is not supported",
+	"pageRank":           "the pageRank() step requires a GraphComputer, which is not supported",
+	"peerPressure":       "the peerPressure() step requires a GraphComputer, which is not supported",
+	"connectedComponent": "the connectedComponent() step requires a GraphComputer, which is not supported",
+	"shortestPath":       "the shortestPath() step requires a GraphComputer, which is not supported",
+}
+
+// traversalSteps are the steps of GraphTraversal and __ (TinkerPop 3.7), other than the terminal steps
+// (e.g. next, toList, iterate) which are only called on a traversal
+var traversalSteps = []string{"V", "E", "addE", "addV", "aggregate", "all", "and", "any", "as", "asDate", "asString",
+	"barrier", "both", "bothE", "bothV", "branch", "by", "call", "cap", "choose", "coalesce", "coin", "combine",
+	"concat", "conjoin", "connectedComponent", "constant", "count", "cyclicPath", "dateAdd", "dateDiff", "dedup",
+	"difference", "disjunct", "drop", "element", "elementMap", "emit", "fail", "filter", "flatMap", "fold", "format",
+	"from", "group", "groupCount", "has", "hasId", "hasKey", "hasLabel", "hasNot", "hasValue", "id", "identity", "in",
+	"inE", "inV", "index", "inject", "intersect", "is", "key", "label", "length", "limit", "local", "loops", "lTrim",
+	"map", "match", "math", "max", "mean", "merge", "mergeE", "mergeV", "min", "none", "not", "option", "optional",
+	"or", "order", "otherV", "out", "outE", "outV", "pageRank", "path", "peerPressure", "product", "profile",
+	"program", "project", "properties", "property", "propertyMap", "range", "read", "repeat", "replace", "reverse",
+	"rTrim", "sack", "sample", "select", "shortestPath", "sideEffect", "simplePath", "skip", "split", "start", "store",
+	"subgraph", "substring", "sum", "tail", "timeLimit", "times", "to", "toE", "toLower", "toUpper", "toV", "tree",
+	"trim", "unfold", "union", "until", "value", "valueMap", "values", "where", "with", "write"}
+
+// steps are the names which may be called without `__.` (as statically imported by Gremlin-Groovy):
+// the traversalSteps which Neptune supports (i.e. not unsupportedSteps), the predicates and the functions
+var steps = func() map[string]bool {
+	s := setOf(traversalSteps...)
+	for name := range unsupportedSteps {
+		delete(s, name)
+	}
+	for _, name := range []string{
+		// predicates
+		"eq", "neq", "lt", "lte", "gt", "gte", "inside", "outside", "between", "within", "without",
+		"startingWith", "endingWith", "containing", "notStartingWith", "notEndingWith", "notContaining",
+		"regex", "notRegex",
+		// functions
+		"datetime",
+	} {
+		s[name] = true
+	}
+	return s
+}()
+
+// receivers are the names (other than the traversal source) whose members may be used, e.g. P.gt or T.id
+var receivers = setOf("g", "__", "P", "TextP", "T", "Order", "Column", "Scope", "Pop", "Cardinality",
+	"VertexProperty", "Direction", "Operator", "Barrier", "Pick", "WithOptions", "Double", "Float")
+
+// constants are the names which may be used as values (as statically imported by Gremlin-Groovy)
+var constants = setOf("true", "false", "null", "single", "list", "set", "id", "label", "key", "value", "keys",
+	"values", "local", "global", "asc", "desc", "shuffle", "first", "last", "all", "mixed", "incr", "decr",
+	"OUT", "IN", "BOTH", "sum", "minus", "mult", "div", "min", "max", "assign", "addAll", "any", "none",
+	"normSack", "tokens")
+
+// keywords are the Groovy keywords which declare or construct things
+var keywords = map[string]Rule{
+	"def":    RuleVariable,
+	"var":    RuleVariable,
+	"new":    RuleClass,
+	"import": RuleClass,
+	"class":  RuleClass,
+}
+
+func setOf(names ...string) map[string]bool {
+	s := make(map[string]bool, len(names))
+	for _, n := range names {
+		s[n] = true
+	}
+	return s
+}
+
+// Lint returns the findings for the features of script which Neptune does not support, in order of position.
+// Any names given are allowed as variables (e.g. those bound server-side).
+func Lint(script string, variables ...string) []Finding {
+	tokens, findings := lex(script)
+	l := linter{script: script, tokens: tokens, findings: findings, variables: setOf(variables...)}
+	l.run()
+	sort.SliceStable(l.findings, func(i, j int) bool { return l.findings[i].Pos.Offset < l.findings[j].Pos.Offset })
+	return l.findings
+}
+
+type linter struct {
+	script    string
+	tokens    []token
+	findings  []Finding
+	variables map[string]bool
+}
+
+func (l *linter) report(tok token, rule Rule, format string, args ...interface{}) {
+	l.findings = append(l.findings, newFinding(l.script, tok.offset, rule, format, args...))
+}
+
+func (l *linter) punct(i int, texts ...string) bool {
+	if i < 0 || i >= len(l.tokens) || l.tokens[i].kind != tokenPunct {
+		return false
+	}
+	for _, t := range texts {
+		if l.tokens[i].text == t {
+			return true
+		}
+	}
+	return false
+}
+
+// run checks each statement (separated by `;`)
+func (l *linter) run() {
+	start := true
+	for i := 0; i < len(l.tokens); i++ {
+		tok := l.tokens[i]
+		if tok.kind == tokenEOF {
+			return
+		}
+		if l.punct(i, ";") {
+			start = true
+			continue
+		}
+		if start {
+			start = false
+			i = l.checkStart(i)
+			if i >= len(l.tokens) || l.tokens[i].kind == tokenEOF {
+				return
+			}
+			tok = l.tokens[i]
+		}
+		if arrow := l.lambdaArrow(i); arrow >= 0 {
+			// its parameters are not variables, and its body is not checked further (as for a closure)
+			l.report(tok, RuleLambda, "lambdas (closures) are not supported")
+			i = l.skipLambda(arrow)
+			continue
+		}
+		switch tok.kind {
+		case tokenString:
+			if tok.interpolated {
+				l.report(tok, RuleInterpolation, "GString interpolation (`$` in a double-quoted string) is not supported")
+			}
+		case tokenPunct:
+			switch tok.text {
+			case "{":
+				l.report(tok, RuleLambda, "lambdas (closures) are not supported")
+				i = l.skipBlock(i)
+			case "::":
+				l.report(tok, RuleLambda, "method references are not supported")
+			case "=", "+=", "-=", "*=", "/=", "++", "--":
+				l.report(tok, RuleVariable, "assignment (to a variable) is not supported")
+			}
+		case tokenIdent:
+			i = l.checkIdent(i)
+		}
+	}
+}
+
+// checkStart checks that the statement starting at token i begins with the traversal source `g`,
+// returning the index of the token from which to continue checking
+func (l *linter) checkStart(i int) int {
+	tok := l.tokens[i]
+	if tok.kind == tokenIdent && tok.text == "g" {
+		return i
+	}
+	if tok.kind == tokenIdent && (keywords[tok.text] == RuleVariable || l.punct(i+1, "=")) {
+		l.report(tok, RuleVariable, "variables are not supported")
+		for i < len(l.tokens) && l.tokens[i].kind != tokenEOF && !l.punct(i, "=", ";") {
+			i++
+		}
+		if l.punct(i, "=") {
+			i++
+		}
+		return i
+	}
+	l.report(tok, RuleStart, "a query must start with the traversal source g")
+	return i
+}
+
+// checkIdent checks the identifier at token i, returning the index of the last token checked
+func (l *linter) checkIdent(i int) int {
+	tok := l.tokens[i]
+	name := tok.text
+	if l.punct(i-1, ".", "?.", "*.") {
+		if l.punct(i+1, "(") {
+			if msg, ok := unsupportedSteps[name]; ok {
+				l.report(tok, RuleStep, msg)
+			}
+		}
+		return i
+	}
+	if rule, ok := keywords[name]; ok {
+		if rule == RuleVariable {
+			l.report(tok, rule, "variables are not supported")
+		} else {
+			l.report(tok, rule, "%q is not supported", name)
+		}
+		// skip the name declared or constructed
+		if i+1 < len(l.tokens) && l.tokens[i+1].kind == tokenIdent {
+			i++
+		}
+		return i
+	}
+	switch {
+	case l.punct(i+1, "("):
+		if msg, ok := unsupportedSteps[name]; ok {
+			l.report(tok, RuleStep, msg)
+		} else if !steps[name] {
+			l.report(tok, RuleFunction, "function %s() is not supported", name)
+		}
+	case l.punct(i+1, ".", "?.", "*."):
+		if receivers[name] || l.variables[name] {
+			return i
+		}
+		if r, _ := utf8.DecodeRuneInString(name); unicode.IsUpper(r) || name == "java" || name == "javax" || name == "groovy" {
+			l.report(tok, RuleClass, "access to class %s is not supported", name)
+		} else {
+			l.report(tok, RuleVariable, "variable %s is not supported", name)
+		}
+	case l.punct(i+1, ":") && l.punct(i-1, "[", ","):
+		// a map key, e.g. [name: 1]
+	default:
+		if !constants[name] && !l.variables[name] {
+			l.report(tok, RuleVariable, "variable %s is not supported", name)
+		}
+	}
+	return i
+}
+
+// lambdaArrow returns the index of the `->` of the lambda whose parameters start at token i,
+// e.g. `v -> v.id()` or `(a, b) -> a`, or -1 if there is none
+func (l *linter) lambdaArrow(i int) int {
+	if i < len(l.tokens) && l.tokens[i].kind == tokenIdent {
+		if l.punct(i+1, "->") {
+			return i + 1
+		}
+		return -1
+	}
+	if !l.punct(i, "(") {
+		return -1
+	}
+	for j := i + 1; j < len(l.tokens); j += 2 {
+		if l.punct(j, ")") && j == i+1 {
+			break
+		}
+		if l.tokens[j].kind != tokenIdent {
+			return -1
+		}
+		if l.punct(j+1, ")") {
+			if l.punct(j+2, "->") {
+				return j + 2
+			}
+			return -1
+		}
+		if !l.punct(j+1, ",") {
+			return -1
+		}
+	}
+	if l.punct(i+2, "->") {
+		return i + 2
+	}
+	return -1
+}
+
+// skipLambda returns the index of the last token of the body of the lambda with the `->` at token i:
+// a block, or an expression ended by a `,` or `;` or the bracket enclosing the lambda
+func (l *linter) skipLambda(i int) int {
+	if l.punct(i+1, "{") {
+		return l.skipBlock(i + 1)
+	}
+	depth := 0
+	for i++; i < len(l.tokens) && l.tokens[i].kind != tokenEOF; i++ {
+		switch {
+		case l.punct(i, "(", "[", "{"):
+			depth++
+		case l.punct(i, ")", "]", "}"):
+			if depth == 0 {
+				return i - 1
+			}
+			depth--
+		case l.punct(i, ",", ";") && depth == 0:
+			return i - 1
+		}
+	}
+	return i - 1
+}
+
+// skipBlock returns the index of the `}` matching the `{` at token i
+func (l *linter) skipBlock(i int) int {
+	depth := 0
+	for ; i < len(l.tokens) && l.tokens[i].kind != tokenEOF; i++ {
+		if l.punct(i, "{") {
+			depth++
+		} else if l.punct(i, "}") {
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return i - 1
+}
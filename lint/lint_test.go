@@ -0,0 +1,150 @@
+package lint
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLintClean(t *testing.T) {
+	for _, script := range []string{
+		"g.V().hasLabel('dataset').has('state', within('a', 'b')).out('hasEdition').limit(10)",
+		"g.V('x').fold().coalesce(unfold(), addV('dataset').property(T.id, 'x')).property(single, 'n', 1.5d)",
+		"g.V().order().by('name', desc).by(id, Order.asc).select(values).unfold().count(local)",
+		"g.V().has('at', gte(datetime('2020-01-01T00:00:00Z'))).valueMap(true)",
+		"g.V().has('name', TextP.startingWith('x')).repeat(__.out()).times(2).emit()\ng.E().drop();\n g.V().iterate()",
+		"g.V().project('a').by(values('a').fold()).where(select('a').is(P.gt(1))) // a comment {",
+		"g.inject([name: 'a', n: 1]).unfold().select(keys)",
+		"g.V().has('s', \"no interpolation\").has('t', 'it\\'s {not} a $lambda')",
+		"g.V().has('n', x).limit(n)",
+		"g.inject(Double.NaN, Float.POSITIVE_INFINITY, [1, 2.5d], -3L)",
+	} {
+		if findings := Lint(script, "x", "n"); len(findings) != 0 {
+			t.Errorf("%s: expected no findings, got %v", script, findings)
+		}
+	}
+}
+
+func TestLint(t *testing.T) {
+	testData := []struct {
+		script   string
+		expected []string
+	}{
+		{"g.V().map{ it.get().value('name') }", []string{"1:10: lambda: lambdas (closures) are not supported"}},
+		{"g.V().map(Lambda.function('it.get()'))", []string{"1:11: class: access to class Lambda is not supported"}},
+		{"System.exit(0)", []string{
+			"1:1: start: a query must start with the traversal source g",
+			"1:1: class: access to class System is not supported",
+		}},
+		{"x = g.V().next()\ng.V(x).out()", []string{
+			"1:1: variable: variables are not supported",
+			"2:5: variable: variable x is not supported",
+		}},
+		{"def v = 1; g.V().has('n', v)", []string{
+			"1:1: variable: variables are not supported",
+			"1:27: variable: variable v is not supported",
+		}},
+		{"g.V().pageRank().program(p)", []string{
+			"1:7: step: the pageRank() step requires a GraphComputer, which is not supported",
+			"1:18: step: the program() step is not supported",
+			"1:26: variable: variable p is not supported",
+		}},
+		{"g.V().has('n', \"${java.lang.Runtime.getRuntime()}\")", []string{
+			"1:16: interpolation: GString interpolation (`$` in a double-quoted string) is not supported",
+		}},
+		{"g.V().has('n', new Date())", []string{
+			"1:16: class: \"new\" is not supported",
+		}},
+		{"g.V().has('n', println('x'))", []string{
+			"1:16: function: function println() is not supported",
+		}},
+		{"g.V().has('n', 'x)", []string{
+			"1:16: syntax: unterminated string",
+		}},
+		{"g.V().values('n').next().n = 1", []string{
+			"1:28: variable: assignment (to a variable) is not supported",
+		}},
+		{"g.V()\n  .has('é', 'ü').map{x -> x}", []string{
+			"2:21: lambda: lambdas (closures) are not supported",
+		}},
+		{"g.V().map{ v -> v.id() }.map(v -> v.id()).filter((a, b) -> a && b).has('n', x)", []string{
+			"1:10: lambda: lambdas (closures) are not supported",
+			"1:30: lambda: lambdas (closures) are not supported",
+			"1:50: lambda: lambdas (closures) are not supported",
+			"1:77: variable: variable x is not supported",
+		}},
+	}
+	for _, td := range testData {
+		var got []string
+		for _, f := range Lint(td.script) {
+			got = append(got, f.String())
+		}
+		if !reflect.DeepEqual(got, td.expected) {
+			t.Errorf("%s:\nexpected %q\ngot      %q", td.script, td.expected, got)
+		}
+	}
+}
+
+func TestLintAnonymousSteps(t *testing.T) {
+	testData := []struct {
+		script   string
+		expected []string
+	}{
+		{"g.V().map(values('x'))", nil},
+		{"g.V().filter(map(values('x')).is(gt(1)))", nil},
+		{"g.V().union(flatMap(out()), branch(label()).option('a', in()))", nil},
+		{"g.V().sideEffect(mergeV(x).mergeE(x)).project('n').by(math('_ + 1'))", nil},
+		{"g.V().has('name', regex('^a')).local(fold().product(constant([1])))", nil},
+		{"g.V().local(pageRank())", []string{
+			"1:13: step: the pageRank() step requires a GraphComputer, which is not supported",
+		}},
+		{"g.V().map(subgraph('s'))", []string{
+			"1:11: step: the subgraph() step is not supported",
+		}},
+		{"g.V().map(mapValues('x'))", []string{
+			"1:11: function: function mapValues() is not supported",
+		}},
+	}
+	for _, td := range testData {
+		var got []string
+		for _, f := range Lint(td.script, "x") {
+			got = append(got, f.String())
+		}
+		if !reflect.DeepEqual(got, td.expected) {
+			t.Errorf("%s:\nexpected %q\ngot      %q", td.script, td.expected, got)
+		}
+	}
+
+	// every step, other than those Neptune does not support, may be an argument
+	for _, name := range traversalSteps {
+		script := "g.V().map(" + name + "())"
+		findings := Lint(script)
+		if _, unsupported := unsupportedSteps[name]; unsupported {
+			if len(findings) != 1 || findings[0].Rule != RuleStep {
+				t.Errorf("%s: expected an unsupported step, got %v", script, findings)
+			}
+		} else if len(findings) != 0 {
+			t.Errorf("%s: expected no findings, got %v", script, findings)
+		}
+	}
+}
+
+func TestLintPosition(t *testing.T) {
+	findings := Lint("g.V()\n.map{}")
+	if len(findings) != 1 || findings[0].Pos != (Position{Offset: 10, Line: 2, Column: 5}) {
+		t.Errorf("Expected a finding at offset 10 (2:5), got %+v", findings)
+	}
+}
+
+// FuzzLint tests that no script can panic the linter, and that findings are within the script
+func FuzzLint(f *testing.F) {
+	for _, seed := range []string{"g.V().map{it}", "x = '", "\"${", "g.V()/*", "def", "new", "a.b.c(", "'''", "é{"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, script string) {
+		for _, finding := range Lint(script) {
+			if finding.Pos.Offset < 0 || finding.Pos.Offset > len(script) {
+				t.Fatalf("%q: finding %v out of range", script, finding)
+			}
+		}
+	})
+}
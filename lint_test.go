@@ -0,0 +1,47 @@
+package gremgo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/gremgo-neptune/lint"
+	"github.com/pkg/errors"
+)
+
+func TestLintMode(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c := newChunksClient()
+	SetLint(true)(c)
+
+	_, err := c.ExecuteCtx(ctx, "g.V().map{ it.get() }", nil, nil)
+	var lintErr *LintError
+	if !errors.Is(err, ErrorLint) || !errors.As(err, &lintErr) || len(lintErr.Findings) != 1 || lintErr.Findings[0].Rule != lint.RuleLambda {
+		t.Fatalf("Expected a lambda finding, got %v", err)
+	}
+	if _, err = c.OpenCursorCtx(ctx, "x = 1", nil, nil); !errors.Is(err, ErrorLint) {
+		t.Errorf("Expected %v opening a cursor, got %v", ErrorLint, err)
+	}
+	if len(c.requests) != 0 {
+		t.Errorf("Expected nothing to be sent, got %d requests", len(c.requests))
+	}
+	assertNoRequestState(t, c)
+
+	sent := sentRequest(t, c)
+	if _, err = c.ExecuteCtx(ctx, "g.V().has('name', x)", map[string]string{"x": "a"}, nil); err != nil {
+		t.Errorf("Expected server-side bindings to be allowed, got %v", err)
+	}
+	<-sent
+
+	SetBindingMode(BindingModeInterpolate)(c)
+	sent = sentRequest(t, c)
+	if _, err = c.ExecuteCtx(ctx, "g.V().has('name', x)", map[string]string{"x": "a"}, nil); err != nil {
+		t.Errorf("Expected interpolated bindings to be allowed, got %v", err)
+	}
+	<-sent
+	if _, err = c.ExecuteCtx(ctx, "g.V().has('name', y)", map[string]string{"x": "a"}, nil); !errors.Is(err, ErrorLint) {
+		t.Errorf("Expected %v for an unbound variable, got %v", ErrorLint, err)
+	}
+}
@@ -0,0 +1,124 @@
+package gremgo
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// GraphMarshaler is implemented by types which marshal themselves as graph properties, in place of the
+// `graph` tag rules of GremlinForVertex, GremlinForEdge and buildProps. MarshalGraph returns the value to read
+// in place of the receiver:
+//   - for a field (or a value of buildProps), a value as for a field tagged without a type option, e.g. a string for
+//     an enum, or a map[string]interface{} for several properties, e.g. {"amount": 150, "currency": "GBP"} for
+//     the properties "price.amount" and "price.currency" of the field tagged `graph:"price"`
+//   - for the data of GremlinForVertex (or GremlinForEdge), a struct with `graph` tags, or a map[string]interface{}
+//     of properties
+type GraphMarshaler interface {
+	MarshalGraph() (interface{}, error)
+}
+
+// GraphUnmarshaler is implemented by types which unmarshal themselves from graph properties, in place of the
+// `graph` tag rules of DecodeVertices and DecodeEdges. UnmarshalGraph is given:
+//   - for a field, the value of its property (or a []interface{} of its values) or, without that property,
+//     a map[string]interface{} of the values of the properties "<name>.<key>" by key (as marshalled from a map),
+//     and is not called if there are neither
+//   - for the destination struct itself, a map[string]interface{} of the values of all the properties
+type GraphUnmarshaler interface {
+	UnmarshalGraph(val interface{}) error
+}
+
+var (
+	graphMarshalerType   = reflect.TypeOf((*GraphMarshaler)(nil)).Elem()
+	graphUnmarshalerType = reflect.TypeOf((*GraphUnmarshaler)(nil)).Elem()
+)
+
+// graphMarshaler returns the GraphMarshaler of v (or of its address), unless it is nil
+func graphMarshaler(v reflect.Value) (GraphMarshaler, bool) {
+	for v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+	switch {
+	case !v.IsValid() || !v.CanInterface():
+		return nil, false
+	case v.Type().Implements(graphMarshalerType):
+		if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+			return nil, false
+		}
+		return v.Interface().(GraphMarshaler), true
+	case v.CanAddr() && reflect.PtrTo(v.Type()).Implements(graphMarshalerType):
+		return v.Addr().Interface().(GraphMarshaler), true
+	}
+	return nil, false
+}
+
+// marshalGraph returns the result of MarshalGraph if v is a GraphMarshaler (see graphMarshaler), else v
+func marshalGraph(v reflect.Value) (res reflect.Value, marshaled bool, err error) {
+	m, ok := graphMarshaler(v)
+	if !ok {
+		return v, false, nil
+	}
+	var val interface{}
+	if val, err = m.MarshalGraph(); err != nil {
+		return v, false, errors.Wrapf(err, "MarshalGraph of %T", m)
+	}
+	return reflect.ValueOf(val), true, nil
+}
+
+// isGraphUnmarshaler returns true if the field fv (or its address) is a GraphUnmarshaler
+func isGraphUnmarshaler(fv reflect.Value) bool {
+	if fv.Kind() == reflect.Ptr && fv.Type().Implements(graphUnmarshalerType) {
+		return true
+	}
+	return fv.CanAddr() && reflect.PtrTo(fv.Type()).Implements(graphUnmarshalerType)
+}
+
+// unmarshalGraph calls UnmarshalGraph(val) on the field fv (see isGraphUnmarshaler), allocating it if a nil pointer
+func unmarshalGraph(fv reflect.Value, val interface{}) error {
+	if fv.Kind() == reflect.Ptr && fv.Type().Implements(graphUnmarshalerType) {
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		return fv.Interface().(GraphUnmarshaler).UnmarshalGraph(val)
+	}
+	return fv.Addr().Interface().(GraphUnmarshaler).UnmarshalGraph(val)
+}
+
+// propertyMapValue returns the value of a property with vals, or vals if there are several
+func propertyMapValue(vals []interface{}) interface{} {
+	if len(vals) == 1 {
+		return vals[0]
+	}
+	return vals
+}
+
+// propertiesMap returns the value (see propertyMapValue) of each property of props
+func propertiesMap(props map[string][]interface{}) map[string]interface{} {
+	m := make(map[string]interface{}, len(props))
+	for key, vals := range props {
+		if len(vals) > 0 {
+			m[key] = propertyMapValue(vals)
+		}
+	}
+	return m
+}
+
+// unmarshalValue returns the value for the GraphUnmarshaler of the property name (see GraphUnmarshaler) from
+// props, the values of each property, returning false if there is none
+func unmarshalValue(props map[string][]interface{}, name string) (interface{}, bool) {
+	if vals := props[name]; len(vals) > 0 {
+		return propertyMapValue(vals), true
+	}
+	prefix := name + "."
+	sub := make(map[string]interface{})
+	for key, vals := range props {
+		if strings.HasPrefix(key, prefix) && len(vals) > 0 {
+			sub[key[len(prefix):]] = propertyMapValue(vals)
+		}
+	}
+	if len(sub) == 0 {
+		return nil, false
+	}
+	return sub, true
+}
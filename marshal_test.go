@@ -0,0 +1,156 @@
+package gremgo
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+type money struct {
+	Pence    int64
+	Currency string
+}
+
+func (m money) MarshalGraph() (interface{}, error) {
+	if m.Currency == "" {
+		return nil, errors.New("no currency")
+	}
+	return map[string]interface{}{"amount": m.Pence, "currency": m.Currency}, nil
+}
+
+func (m *money) UnmarshalGraph(val interface{}) error {
+	props, ok := val.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("expected the properties of money, got %T", val)
+	}
+	pence, err := ToInt64(props["amount"])
+	if err != nil {
+		return err
+	}
+	m.Pence, m.Currency = pence, fmt.Sprint(props["currency"])
+	return nil
+}
+
+type state int
+
+const (
+	stateDraft state = iota
+	statePublished
+)
+
+var stateNames = []string{"draft", "published"}
+
+func (s state) MarshalGraph() (interface{}, error) {
+	return stateNames[s], nil
+}
+
+func (s *state) UnmarshalGraph(val interface{}) error {
+	for i, name := range stateNames {
+		if val == name {
+			*s = state(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown state %v", val)
+}
+
+type product struct {
+	Id     string
+	Name   string  `graph:"name,string"`
+	Price  money   `graph:"price"`
+	Was    *money  `graph:"was"`
+	State  state   `graph:"state,number"`
+	States []state `graph:"states,[]other"`
+}
+
+// productMap marshals itself as a map of properties
+type productMap struct {
+	Name string
+}
+
+func (p productMap) MarshalGraph() (interface{}, error) {
+	return map[string]interface{}{"name": p.Name, "price": money{Pence: 1, Currency: "GBP"}}, nil
+}
+
+const dummyProduct = `{"@type":"g:List","@value":[{"@type":"g:Vertex","@value":{"id":"p1","label":"product","properties":{` +
+	`"name":[{"@type":"g:VertexProperty","@value":{"id":{"@type":"Type","@value":1},"value":"tea","label":"name"}}],` +
+	`"price.amount":[{"@type":"g:VertexProperty","@value":{"id":{"@type":"Type","@value":2},"value":{"@type":"g:Int64","@value":150},"label":"price.amount"}}],` +
+	`"price.currency":[{"@type":"g:VertexProperty","@value":{"id":{"@type":"Type","@value":3},"value":"GBP","label":"price.currency"}}],` +
+	`"state":[{"@type":"g:VertexProperty","@value":{"id":{"@type":"Type","@value":4},"value":"published","label":"state"}}],` +
+	`"states":[{"@type":"g:VertexProperty","@value":{"id":{"@type":"Type","@value":5},"value":"draft","label":"states"}},` +
+	`{"@type":"g:VertexProperty","@value":{"id":{"@type":"Type","@value":6},"value":"published","label":"states"}}]}}}]}`
+
+func TestGraphMarshaler(t *testing.T) {
+	testData := []struct {
+		data     interface{}
+		expected string
+	}{
+		{
+			product{Id: "p1", Name: "tea", Price: money{150, "GBP"}, State: statePublished, States: []state{stateDraft}},
+			`addV('product').property(id,'p1').property('name','tea').property('price.amount',150).property('price.currency','GBP')` +
+				`.property('state','published').property('states','draft')`,
+		},
+		{
+			&product{Name: "tea", Price: money{150, "GBP"}, Was: &money{200, "GBP"}},
+			`addV('product').property('name','tea').property('price.amount',150).property('price.currency','GBP')` +
+				`.property('was.amount',200).property('was.currency','GBP').property('state','draft')`,
+		},
+		{
+			productMap{Name: "tea"},
+			`addV('product').property('name','tea').property('price.amount',1).property('price.currency','GBP')`,
+		},
+	}
+	for _, td := range testData {
+		add, _, err := GremlinForVertex("product", td.data)
+		if err != nil || add != td.expected {
+			t.Errorf("Expected\n%s\ngot\n%s %v", td.expected, add, err)
+		}
+	}
+
+	if _, _, err := GremlinForVertex("product", product{Name: "tea"}); err == nil {
+		t.Error("Expected the error of MarshalGraph")
+	}
+
+	q, err := buildProps(map[string]interface{}{"price": money{5, "EUR"}, "state": statePublished})
+	if expected := `.property('price.amount', 5).property('price.currency', 'EUR').property('state', 'published')`; err != nil || q != expected {
+		t.Errorf("Expected %s, got %s %v", expected, q, err)
+	}
+}
+
+func TestGraphUnmarshaler(t *testing.T) {
+	verts := dummyVertices(t, dummyProduct)
+	expected := product{Id: "p1", Name: "tea", Price: money{150, "GBP"}, State: statePublished, States: []state{stateDraft, statePublished}}
+
+	var res []product
+	if err := DecodeVertices(verts, &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 || !reflect.DeepEqual(res[0], expected) {
+		t.Errorf("Expected %+v, got %+v", expected, res)
+	}
+
+	verts[0].Value.Properties["state"][0].Value.Value = "unknown"
+	if err := DecodeVertices(verts, &res); err == nil {
+		t.Error("Expected the error of UnmarshalGraph")
+	}
+
+	var m productProps
+	if err := DecodeVertex(verts[0], &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.props["name"] != "tea" || m.props["price.currency"] != "GBP" {
+		t.Errorf("Unexpected properties %v", m.props)
+	}
+}
+
+// productProps unmarshals itself from all its properties
+type productProps struct {
+	props map[string]interface{}
+}
+
+func (p *productProps) UnmarshalGraph(val interface{}) error {
+	p.props = val.(map[string]interface{})
+	return nil
+}
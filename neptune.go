@@ -0,0 +1,134 @@
+package gremgo
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Error codes reported by AWS Neptune
+// (see https://docs.aws.amazon.com/neptune/latest/userguide/errors-engine-codes.html)
+const (
+	NeptuneAccessDenied           = "AccessDeniedException"
+	NeptuneBadRequest             = "BadRequestException"
+	NeptuneCancelledByUser        = "CancelledByUserException"
+	NeptuneConcurrentModification = "ConcurrentModificationException"
+	NeptuneConstraintViolation    = "ConstraintViolationException"
+	NeptuneFailureByQuery         = "FailureByQueryException"
+	NeptuneIllegalArgument        = "IllegalArgumentException"
+	NeptuneInternalFailure        = "InternalFailureException"
+	NeptuneInvalidArgument        = "InvalidArgumentException"
+	NeptuneInvalidNumericData     = "InvalidNumericDataException"
+	NeptuneInvalidParameter       = "InvalidParameterException"
+	NeptuneMalformedQuery         = "MalformedQueryException"
+	NeptuneMemoryLimitExceeded    = "MemoryLimitExceededException"
+	NeptuneMissingParameter       = "MissingParameterException"
+	NeptuneQueryLimitExceeded     = "QueryLimitExceededException"
+	NeptuneQueryLimit             = "QueryLimitException"
+	NeptuneQueryTooLarge          = "QueryTooLargeException"
+	NeptuneReadOnlyViolation      = "ReadOnlyViolationException"
+	NeptuneThrottling             = "ThrottlingException"
+	NeptuneTimeLimitExceeded      = "TimeLimitExceededException"
+	NeptuneTooManyRequests        = "TooManyRequestsException"
+	NeptuneUnsupportedOperation   = "UnsupportedOperationException"
+)
+
+// neptuneRetryable are the error codes for which Neptune advises that the request may be retried
+var neptuneRetryable = map[string]bool{
+	NeptuneCancelledByUser:        true,
+	NeptuneConcurrentModification: true,
+	NeptuneConstraintViolation:    true,
+	NeptuneFailureByQuery:         true,
+	NeptuneInternalFailure:        true,
+	NeptuneMemoryLimitExceeded:    true,
+	NeptuneQueryLimitExceeded:     true,
+	NeptuneThrottling:             true,
+	NeptuneTimeLimitExceeded:      true,
+	NeptuneTooManyRequests:        true,
+}
+
+// NeptuneError is the error for a response from Neptune, which encodes its error as JSON in the status message.
+// It wraps the *ServerError for the response, so errors.Is and errors.As work as for any other server error.
+type NeptuneError struct {
+	Code            string       `json:"code"`
+	DetailedMessage string       `json:"detailedMessage"`
+	RequestID       string       `json:"requestId"`
+	ServerError     *ServerError `json:"-"`
+}
+
+// parseNeptuneError returns the NeptuneError encoded in the message of serverErr, or nil if there is none
+func parseNeptuneError(serverErr *ServerError) *NeptuneError {
+	msg := strings.TrimSpace(serverErr.Message)
+	if !strings.HasPrefix(msg, "{") {
+		return nil
+	}
+	var neptuneErr NeptuneError
+	if err := json.Unmarshal([]byte(msg), &neptuneErr); err != nil || neptuneErr.Code == "" {
+		return nil
+	}
+	neptuneErr.ServerError = serverErr
+	return &neptuneErr
+}
+
+// Error returns the status of the response and the Neptune error code and message.
+// The status is omitted if there is no ServerError (e.g. for a NeptuneError built as an errors.As target).
+func (e *NeptuneError) Error() string {
+	if e.ServerError == nil {
+		return fmt.Sprintf("%s: %s", e.Code, e.DetailedMessage)
+	}
+	return fmt.Sprintf("%s - %s: %s", strings.ToUpper(e.ServerError.status().Error()), e.Code, e.DetailedMessage)
+}
+
+// Unwrap returns the *ServerError for the response, or nil if there is none
+func (e *NeptuneError) Unwrap() error {
+	if e.ServerError == nil {
+		return nil
+	}
+	return e.ServerError
+}
+
+// IsRetryable returns true if Neptune advises that the request may be retried
+func (e *NeptuneError) IsRetryable() bool {
+	return neptuneRetryable[e.Code]
+}
+
+// neptuneErrorCode returns the Neptune error code within err, or "" if err is not from Neptune
+func neptuneErrorCode(err error) string {
+	var neptuneErr *NeptuneError
+	if errors.As(err, &neptuneErr) {
+		return neptuneErr.Code
+	}
+	return ""
+}
+
+// IsRetryable returns true if err is a NeptuneError for which the request may be retried
+func IsRetryable(err error) bool {
+	return neptuneRetryable[neptuneErrorCode(err)]
+}
+
+// IsConcurrentModification returns true if err is a NeptuneError for conflicting concurrent operations
+func IsConcurrentModification(err error) bool {
+	return neptuneErrorCode(err) == NeptuneConcurrentModification
+}
+
+// IsReadOnlyViolation returns true if err is a NeptuneError for a write sent to a read-only (replica) instance
+func IsReadOnlyViolation(err error) bool {
+	return neptuneErrorCode(err) == NeptuneReadOnlyViolation
+}
+
+// IsConstraintViolation returns true if err is a NeptuneError for a violated constraint (e.g. a duplicate vertex id)
+func IsConstraintViolation(err error) bool {
+	return neptuneErrorCode(err) == NeptuneConstraintViolation
+}
+
+// IsMemoryLimitExceeded returns true if err is a NeptuneError for a query that ran out of memory
+func IsMemoryLimitExceeded(err error) bool {
+	return neptuneErrorCode(err) == NeptuneMemoryLimitExceeded
+}
+
+// IsTimeLimitExceeded returns true if err is a NeptuneError for a query that exceeded the server's query timeout
+func IsTimeLimitExceeded(err error) bool {
+	return neptuneErrorCode(err) == NeptuneTimeLimitExceeded
+}
@@ -0,0 +1,152 @@
+package gremgo
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// recorded Neptune error responses
+var (
+	dummyNeptuneConcurrentModification = []byte(`{"requestId":"6a2b1f4e-4f3c-4d6e-9a59-0b3d8e7b6c11","status":{"message":"{\"detailedMessage\":\"Failed to complete Insert operation for a Vertex due to conflicting concurrent operations. Please retry. 0 transactions are currently rolling back.\",\"requestId\":\"6a2b1f4e-4f3c-4d6e-9a59-0b3d8e7b6c11\",\"code\":\"ConcurrentModificationException\"}","code":500,"attributes":{"exceptions":["ConcurrentModificationException"],"stackTrace":"ConcurrentModificationException: Failed to complete Insert operation"}},"result":{"data":null,"meta":{}}}`)
+	dummyNeptuneReadOnlyViolation      = []byte(`{"requestId":"0f8e9f3a-1c1b-4e0e-8c6a-2d4b5e6f7a81","status":{"message":"{\"detailedMessage\":\"The request is rejected because it violates some read-only restriction, such as a designation of a replica as read-only.\",\"requestId\":\"0f8e9f3a-1c1b-4e0e-8c6a-2d4b5e6f7a81\",\"code\":\"ReadOnlyViolationException\"}","code":499,"attributes":{"exceptions":["ReadOnlyViolationException"]}},"result":{"data":null,"meta":{}}}`)
+	dummyNeptuneConstraintViolation    = []byte(`{"requestId":"9c1d2e3f-7a6b-4c5d-8e9f-0a1b2c3d4e51","status":{"message":"{\"detailedMessage\":\"Vertex with id already exists: 1234\",\"requestId\":\"9c1d2e3f-7a6b-4c5d-8e9f-0a1b2c3d4e51\",\"code\":\"ConstraintViolationException\"}","code":500,"attributes":{"exceptions":["ConstraintViolationException"]}},"result":{"data":null,"meta":{}}}`)
+	dummyNeptuneMemoryLimitExceeded    = []byte(`{"requestId":"1e2d3c4b-5a69-4788-9a0b-1c2d3e4f5a61","status":{"message":"{\"detailedMessage\":\"Query cannot be completed due to memory limitations.\",\"requestId\":\"1e2d3c4b-5a69-4788-9a0b-1c2d3e4f5a61\",\"code\":\"MemoryLimitExceededException\"}","code":500,"attributes":{"exceptions":["MemoryLimitExceededException"]}},"result":{"data":null,"meta":{}}}`)
+	dummyNeptuneTimeLimitExceeded      = []byte(`{"requestId":"2f3e4d5c-6b7a-4899-8a1b-2c3d4e5f6a71","status":{"message":"{\"detailedMessage\":\"A timeout occurred within the script during evaluation.\",\"requestId\":\"2f3e4d5c-6b7a-4899-8a1b-2c3d4e5f6a71\",\"code\":\"TimeLimitExceededException\"}","code":598,"attributes":{"exceptions":["TimeLimitExceededException"]}},"result":{"data":null,"meta":{}}}`)
+	dummyNeptuneMalformedQuery         = []byte(`{"requestId":"3a4b5c6d-7e8f-4a9b-8c0d-3e4f5a6b7c81","status":{"message":"{\"detailedMessage\":\"Failed to interpret Gremlin query: Query parsing failed at line 1, character position at 4, error message : no viable alternative at input 'g.V(('\",\"requestId\":\"3a4b5c6d-7e8f-4a9b-8c0d-3e4f5a6b7c81\",\"code\":\"MalformedQueryException\"}","code":499,"attributes":{"exceptions":["MalformedQueryException"]}},"result":{"data":null,"meta":{}}}`)
+	dummyGremlinServerError            = []byte(`{"requestId":"4b5c6d7e-8f9a-4b0c-9d1e-4f5a6b7c8d91","status":{"message":"BOOM","code":500,"attributes":{}},"result":{"data":null,"meta":{}}}`)
+)
+
+func TestNeptuneError(t *testing.T) {
+	type isFunc func(error) bool
+	testData := []struct {
+		title         string
+		msg           []byte
+		expectCode    string
+		expectStatus  error
+		expectDetail  string
+		expectIs      []isFunc
+		expectIsNot   []isFunc
+		expectNeptune bool
+	}{
+		{
+			title:         "concurrent modification",
+			msg:           dummyNeptuneConcurrentModification,
+			expectNeptune: true,
+			expectCode:    NeptuneConcurrentModification,
+			expectStatus:  ErrServerError,
+			expectDetail:  "Failed to complete Insert operation for a Vertex due to conflicting concurrent operations. Please retry. 0 transactions are currently rolling back.",
+			expectIs:      []isFunc{IsRetryable, IsConcurrentModification},
+			expectIsNot:   []isFunc{IsReadOnlyViolation, IsConstraintViolation, IsMemoryLimitExceeded},
+		},
+		{
+			title:         "read-only violation",
+			msg:           dummyNeptuneReadOnlyViolation,
+			expectNeptune: true,
+			expectCode:    NeptuneReadOnlyViolation,
+			expectStatus:  ErrInvalidRequestArguments,
+			expectIs:      []isFunc{IsReadOnlyViolation},
+			expectIsNot:   []isFunc{IsRetryable, IsConcurrentModification, IsConstraintViolation},
+		},
+		{
+			title:         "constraint violation",
+			msg:           dummyNeptuneConstraintViolation,
+			expectNeptune: true,
+			expectCode:    NeptuneConstraintViolation,
+			expectStatus:  ErrServerError,
+			expectDetail:  "Vertex with id already exists: 1234",
+			expectIs:      []isFunc{IsRetryable, IsConstraintViolation},
+			expectIsNot:   []isFunc{IsReadOnlyViolation, IsMemoryLimitExceeded},
+		},
+		{
+			title:         "memory limit exceeded",
+			msg:           dummyNeptuneMemoryLimitExceeded,
+			expectNeptune: true,
+			expectCode:    NeptuneMemoryLimitExceeded,
+			expectStatus:  ErrServerError,
+			expectIs:      []isFunc{IsRetryable, IsMemoryLimitExceeded},
+			expectIsNot:   []isFunc{IsTimeLimitExceeded},
+		},
+		{
+			title:         "time limit exceeded",
+			msg:           dummyNeptuneTimeLimitExceeded,
+			expectNeptune: true,
+			expectCode:    NeptuneTimeLimitExceeded,
+			expectStatus:  ErrServerTimeout,
+			expectIs:      []isFunc{IsRetryable, IsTimeLimitExceeded},
+			expectIsNot:   []isFunc{IsMemoryLimitExceeded},
+		},
+		{
+			title:         "malformed query",
+			msg:           dummyNeptuneMalformedQuery,
+			expectNeptune: true,
+			expectCode:    NeptuneMalformedQuery,
+			expectStatus:  ErrInvalidRequestArguments,
+			expectIsNot:   []isFunc{IsRetryable, IsConstraintViolation},
+		},
+		{
+			title:        "gremlin server (not neptune)",
+			msg:          dummyGremlinServerError,
+			expectStatus: ErrServerError,
+			expectIsNot:  []isFunc{IsRetryable, IsConcurrentModification, IsReadOnlyViolation, IsConstraintViolation, IsMemoryLimitExceeded},
+		},
+	}
+
+	for _, test := range testData {
+		_, err := marshalResponse(test.msg)
+		if err == nil {
+			t.Errorf("%s: expected an error", test.title)
+			continue
+		}
+		// wrapped as it would be when returned to the requester
+		err = errors.Wrap(err, "query: g.V()")
+
+		if !errors.Is(err, test.expectStatus) {
+			t.Errorf("%s: expected %v, got %v", test.title, test.expectStatus, err)
+		}
+		var serverErr *ServerError
+		if !errors.As(err, &serverErr) {
+			t.Errorf("%s: expected a *ServerError, got %T", test.title, errors.Cause(err))
+		}
+
+		var neptuneErr *NeptuneError
+		if isNeptune := errors.As(err, &neptuneErr); isNeptune != test.expectNeptune {
+			t.Errorf("%s: expected NeptuneError to be %v, got %v", test.title, test.expectNeptune, isNeptune)
+		} else if isNeptune {
+			if neptuneErr.Code != test.expectCode {
+				t.Errorf("%s: expected code %q, got %q", test.title, test.expectCode, neptuneErr.Code)
+			}
+			if neptuneErr.RequestID != serverErr.RequestID {
+				t.Errorf("%s: expected requestId %q, got %q", test.title, serverErr.RequestID, neptuneErr.RequestID)
+			}
+			if test.expectDetail != "" && neptuneErr.DetailedMessage != test.expectDetail {
+				t.Errorf("%s: expected detailedMessage %q, got %q", test.title, test.expectDetail, neptuneErr.DetailedMessage)
+			}
+		}
+
+		for idx, is := range test.expectIs {
+			if !is(err) {
+				t.Errorf("%s: expected expectIs[%d] to be true for %v", test.title, idx, err)
+			}
+		}
+		for idx, is := range test.expectIsNot {
+			if is(err) {
+				t.Errorf("%s: expected expectIsNot[%d] to be false for %v", test.title, idx, err)
+			}
+		}
+	}
+}
+
+func TestNeptuneErrorWithoutServerError(t *testing.T) {
+	for _, neptuneErr := range []*NeptuneError{{}, {Code: NeptuneThrottling, DetailedMessage: "slow down"}} {
+		if msg := neptuneErr.Error(); msg != neptuneErr.Code+": "+neptuneErr.DetailedMessage {
+			t.Errorf("unexpected message %q", msg)
+		}
+		if neptuneErr.Unwrap() != nil {
+			t.Errorf("expected no wrapped error, got %v", neptuneErr.Unwrap())
+		}
+		if errors.Is(neptuneErr, ErrServerError) {
+			t.Errorf("expected %v not to be %v", neptuneErr, ErrServerError)
+		}
+	}
+}
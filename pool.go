@@ -49,9 +49,14 @@ func NewPool(dial func() (*Client, error)) *Pool {
 // NewPoolWithDialerCtx returns a NewPool that uses a contextual dialer to dbURL,
 // errs is a chan that receives any errors from the ping/read/write workers for the connection
 func NewPoolWithDialerCtx(ctx context.Context, dbURL string, errs chan error, cfgs ...DialerConfig) *Pool {
+	return NewPoolWithClientConfigCtx(ctx, dbURL, errs, nil, cfgs...)
+}
+
+// NewPoolWithClientConfigCtx is NewPoolWithDialerCtx, where each client in the pool is also configured with clientCfgs
+func NewPoolWithClientConfigCtx(ctx context.Context, dbURL string, errs chan error, clientCfgs []ClientConfig, cfgs ...DialerConfig) *Pool {
 	dialFunc := func() (*Client, error) {
 		dialer := NewDialer(dbURL, cfgs...)
-		cli, err := DialCtx(ctx, dialer, errs)
+		cli, err := DialCtx(ctx, dialer, errs, clientCfgs...)
 		return cli, err
 	}
 	return NewPool(dialFunc)
@@ -345,11 +350,12 @@ func (p *Pool) ExecuteCtx(ctx context.Context, query string, bindings, rebinding
 	defer func() {
 		p.putConn(pc, err)
 	}()
-	resp, err = pc.Client.executeRequestCtx(ctx, query, bindings, rebindings)
+	resp, err = pc.Client.executeRequestCtx(ctx, query, stringBindings(bindings), rebindings)
 	return
 }
 
 // ExecuteFile takes a file path to a Gremlin script, sends it to Gremlin Server, and returns the result.
+// For scripts of several statements, see ExecuteScriptFileCtx.
 func (p *Pool) ExecuteFile(path string, bindings, rebindings map[string]string) (resp []Response, err error) {
 	pc, err := p.conn()
 	if err != nil {
@@ -364,7 +370,7 @@ func (p *Pool) ExecuteFile(path string, bindings, rebindings map[string]string)
 		return
 	}
 	query := string(d)
-	resp, err = pc.Client.executeRequest(query, bindings, rebindings)
+	resp, err = pc.Client.executeRequest(query, stringBindings(bindings), rebindings)
 	return
 }
 
@@ -423,17 +429,67 @@ func (p *Pool) OpenCursorCtx(ctx context.Context, query string, bindings, rebind
 	return pc.Client.OpenCursorCtx(ctx, query, bindings, rebindings)
 }
 
+// OpenValueCursorCtx initiates a query on the database, returning a ValueCursor to iterate over the results
+// (which reads from the pooled client that ran the query)
+func (p *Pool) OpenValueCursorCtx(ctx context.Context, query string, bindings, rebindings map[string]string) (cursor *ValueCursor, err error) {
+	var pc *conn
+	if pc, err = p.connCtx(ctx); err != nil {
+		err = errors.Wrap(err, "OpenValueCursorCtx: Failed p.connCtx")
+		return
+	}
+	defer p.putConn(pc, err)
+	return pc.Client.OpenValueCursorCtx(ctx, query, bindings, rebindings)
+}
+
 // ReadCursorCtx returns the next set of results for the cursor
 // - `res` returns vertices (and may be empty when results were read by a previous call - this is normal)
 // - `eof` will be true when no more results are available (`res` may still have results)
+// The cursor is read by the client (connection) which opened it, as only that client receives its results.
 func (p *Pool) ReadCursorCtx(ctx context.Context, cursor *Cursor) (res []graphson.Vertex, eof bool, err error) {
+	if cursor.client == nil {
+		return nil, false, errors.Wrapf(ErrorUnknownCursor, "ReadCursorCtx: %s", cursor.ID)
+	}
+	return cursor.client.ReadCursorCtx(ctx, cursor)
+}
+
+// GetIntoCtx executes a query which returns vertices, and decodes them into dest (see Client.GetIntoCtx)
+func (p *Pool) GetIntoCtx(ctx context.Context, query string, bindings, rebindings map[string]string, dest interface{}) (err error) {
 	var pc *conn
 	if pc, err = p.connCtx(ctx); err != nil {
-		err = errors.Wrap(err, "NextCtx: Failed p.connCtx")
-		return
+		return errors.Wrap(err, "GetIntoCtx: Failed p.connCtx")
+	}
+	defer p.putConn(pc, err)
+	return pc.Client.GetIntoCtx(ctx, query, bindings, rebindings, dest)
+}
+
+// ReadCursorIntoCtx decodes the next set of results for the cursor into dest (see Client.ReadCursorIntoCtx),
+// reading the cursor by the client which opened it (as for ReadCursorCtx)
+func (p *Pool) ReadCursorIntoCtx(ctx context.Context, cursor *Cursor, dest interface{}) (eof bool, err error) {
+	if cursor.client == nil {
+		return false, errors.Wrapf(ErrorUnknownCursor, "ReadCursorIntoCtx: %s", cursor.ID)
+	}
+	return cursor.client.ReadCursorIntoCtx(ctx, cursor, dest)
+}
+
+// QueryCtx executes a query, returning its results decoded as a List of values (see Client.QueryCtx)
+func (p *Pool) QueryCtx(ctx context.Context, query string, bindings, rebindings map[string]string) (res List, err error) {
+	var pc *conn
+	if pc, err = p.connCtx(ctx); err != nil {
+		return nil, errors.Wrap(err, "QueryCtx: Failed p.connCtx")
+	}
+	defer p.putConn(pc, err)
+	return pc.Client.QueryCtx(ctx, query, bindings, rebindings)
+}
+
+// QueryChunksCtx executes a query, passing the decoded results of each response to consume as it arrives
+// (see Client.QueryChunksCtx)
+func (p *Pool) QueryChunksCtx(ctx context.Context, query string, bindings, rebindings map[string]string, consume ChunkConsumer) (err error) {
+	var pc *conn
+	if pc, err = p.connCtx(ctx); err != nil {
+		return errors.Wrap(err, "QueryChunksCtx: Failed p.connCtx")
 	}
 	defer p.putConn(pc, err)
-	return pc.Client.ReadCursorCtx(ctx, cursor)
+	return pc.Client.QueryChunksCtx(ctx, query, bindings, rebindings, consume)
 }
 
 // AddE
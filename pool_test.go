@@ -1,9 +1,13 @@
 package gremgo
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"testing"
 	"time"
+
+	"github.com/pkg/errors"
 )
 
 var dummyDialFunc func() (*Client, error)
@@ -200,3 +204,35 @@ func TestGetAndDial(t *testing.T) {
 		t.Error("Expected the same connection to be reused")
 	}
 }
+
+func TestPoolReadCursorInto(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// the cursor is opened by c, but the pool's free connection is another client
+	c := newChunksClient()
+	p := NewPool(dummyDialFunc)
+	defer p.Close()
+	p.freeConns = []*conn{{Pool: p, Client: &Client{}, t: time.Now()}}
+	p.open = 1
+
+	go func() { <-c.requests }()
+	cursor, err := c.OpenCursorCtx(ctx, "g.V().hasLabel('dataset')", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.saveResponse(Response{RequestID: cursor.ID, Status: Status{Code: StatusSuccess}, Result: Result{Data: json.RawMessage(dummyDecodeVertices)}}, nil)
+
+	var res []decodeDataset
+	eof, err := p.ReadCursorIntoCtx(ctx, cursor, &res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !eof || len(res) != 2 || res[0].Name != "cpih" {
+		t.Errorf("Expected eof and 2 datasets, got %v %+v", eof, res)
+	}
+
+	if _, err = p.ReadCursorIntoCtx(ctx, &Cursor{ID: cursor.ID}, &res); errors.Cause(err) != ErrorUnknownCursor {
+		t.Errorf("Expected %v, got %v", ErrorUnknownCursor, err)
+	}
+}
@@ -0,0 +1,115 @@
+package gremgo
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// defaultReapInterval is how often the reaper runs when no response timeout is configured
+	defaultReapInterval = time.Minute
+	// expiredRetention is how long an expired requestID is remembered, so that its late responses are discarded
+	expiredRetention = 10 * time.Minute
+)
+
+// inflightRequest is the context of an in-flight request, and the time of its last activity
+type inflightRequest struct {
+	ctx          context.Context
+	lastActivity time.Time
+}
+
+// trackRequest records the request, made with ctx, as in flight, for expiry by the reaper
+func (c *Client) trackRequest(ctx context.Context, id string) {
+	c.inflight.Store(id, inflightRequest{ctx: ctx, lastActivity: time.Now()})
+}
+
+// touchRequest records activity (e.g. a partial response) on an in-flight request
+func (c *Client) touchRequest(id string) {
+	if req, ok := c.inflight.Load(id); ok {
+		c.inflight.Store(id, inflightRequest{ctx: req.(inflightRequest).ctx, lastActivity: time.Now()})
+	}
+}
+
+// isExpired returns true when the request has been abandoned (its responses are to be discarded)
+func (c *Client) isExpired(id string) bool {
+	_, ok := c.expired.Load(id)
+	return ok
+}
+
+// expireRequest abandons the request: all state held for it is released,
+// and any responses that arrive later for it will be discarded by saveResponse.
+// Notifier channels are not closed, as saveResponse may still hold them, but the chunk notifier is drained
+// so that saveResponse cannot remain blocked on a chunk which will never be read.
+func (c *Client) expireRequest(id string) {
+	c.Lock()
+	c.expired.Store(id, time.Now())
+	c.inflight.Delete(id)
+	c.responseSizes.Delete(id)
+	c.responseNotifier.Delete(id)
+	chunkNotifier, _ := c.chunkNotifier.Load(id)
+	c.chunkNotifier.Delete(id)
+	c.releaseChunks(id)
+	c.deleteResponse(id)
+	c.Unlock()
+
+	if chunkNotifier == nil {
+		return
+	}
+	for {
+		select {
+		case <-chunkNotifier.(chan bool):
+		default:
+			return
+		}
+	}
+}
+
+// reapInterval returns how often the reaper should check for orphaned requests
+func (c *Client) reapInterval() time.Duration {
+	if c.responseTimeout > 0 && c.responseTimeout/2 < defaultReapInterval {
+		return c.responseTimeout / 2
+	}
+	return defaultReapInterval
+}
+
+// reapWorkerCtx works on a loop, expiring orphaned requests until the client is closed
+func (c *Client) reapWorkerCtx(ctx context.Context) {
+	ticker := time.NewTicker(c.reapInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			c.reap(now)
+		case <-ctx.Done():
+			return
+		case <-c.quit:
+			return
+		}
+	}
+}
+
+// reap expires requests whose context is done (e.g. cursors abandoned by cancelling their context), and those which
+// have seen no activity within the response timeout (when set), and forgets expired requests which are older than
+// expiredRetention
+func (c *Client) reap(now time.Time) {
+	deadline := now.Add(-c.responseTimeout)
+	c.inflight.Range(func(key, val interface{}) bool {
+		req := val.(inflightRequest)
+		if req.ctx.Err() != nil || (c.responseTimeout > 0 && req.lastActivity.Before(deadline)) {
+			c.expireRequest(key.(string))
+		}
+		return true
+	})
+
+	retention := expiredRetention
+	if c.responseTimeout > retention {
+		retention = c.responseTimeout
+	}
+	forgetBefore := now.Add(-retention)
+	c.expired.Range(func(key, val interface{}) bool {
+		if val.(time.Time).Before(forgetBefore) {
+			c.expired.Delete(key)
+		}
+		return true
+	})
+}
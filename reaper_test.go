@@ -0,0 +1,218 @@
+package gremgo
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func syncMapLen(m *sync.Map) (n int) {
+	m.Range(func(key, val interface{}) bool {
+		n++
+		return true
+	})
+	return
+}
+
+// drainRequests fakes the writeWorker, returning a func which stops it and returns the requestIDs written
+func drainRequests(t *testing.T, c *Client) func() []string {
+	var ids []string
+	quit := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case msg := <-c.requests:
+				var req request
+				if err := json.Unmarshal(msg[len(mimeTypePrefix):], &req); err != nil {
+					t.Error(err)
+					continue
+				}
+				ids = append(ids, req.RequestID)
+			case <-quit:
+				return
+			}
+		}
+	}()
+	return func() []string {
+		close(quit)
+		<-done
+		return ids
+	}
+}
+
+func lateResponses(c *Client, ids []string) {
+	for _, id := range ids {
+		c.saveResponse(Response{RequestID: id, Status: Status{Code: StatusPartialContent}, Result: Result{Data: []byte("late1")}}, nil)
+		c.saveResponse(Response{RequestID: id, Status: Status{Code: StatusSuccess}, Result: Result{Data: []byte("late2")}}, nil)
+	}
+}
+
+func assertNoRequestState(t *testing.T, c *Client) {
+	t.Helper()
+	if n := syncMapLen(c.results); n != 0 {
+		t.Errorf("Expected no results, got %d", n)
+	}
+	if n := syncMapLen(c.responseNotifier); n != 0 {
+		t.Errorf("Expected no responseNotifiers, got %d", n)
+	}
+	if n := syncMapLen(c.chunkNotifier); n != 0 {
+		t.Errorf("Expected no chunkNotifiers, got %d", n)
+	}
+	if n := syncMapLen(c.chunkHolds); n != 0 {
+		t.Errorf("Expected no chunkHolds, got %d", n)
+	}
+	if n := syncMapLen(c.inflight); n != 0 {
+		t.Errorf("Expected no inflight requests, got %d", n)
+	}
+	if n := syncMapLen(c.responseSizes); n != 0 {
+		t.Errorf("Expected no responseSizes, got %d", n)
+	}
+}
+
+func TestCancelledRequestsAreReaped(t *testing.T) {
+	const numRequests = 2000
+	c := newClient()
+	stop := drainRequests(t, c)
+
+	var wg sync.WaitGroup
+	for i := 0; i < numRequests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			if _, err := c.executeRequestCtx(ctx, "g.V()", nil, nil); errors.Cause(err) != context.DeadlineExceeded {
+				t.Errorf("Expected %v, got %v", context.DeadlineExceeded, err)
+			}
+		}()
+	}
+	wg.Wait()
+	ids := stop()
+	if len(ids) == 0 {
+		t.Fatal("Expected requests to be written")
+	}
+
+	lateResponses(c, ids)
+	assertNoRequestState(t, c)
+	if n := syncMapLen(c.expired); n != numRequests {
+		t.Errorf("Expected %d expired requests, got %d", numRequests, n)
+	}
+
+	c.reap(time.Now().Add(expiredRetention + time.Second))
+	if n := syncMapLen(c.expired); n != 0 {
+		t.Errorf("Expected expired requests to be forgotten, got %d", n)
+	}
+}
+
+func TestResponseTimeout(t *testing.T) {
+	c := newClient()
+	SetResponseTimeout(20 * time.Millisecond)(c)
+	stop := drainRequests(t, c)
+
+	_, err := c.executeRequestCtx(context.Background(), "g.V()", nil, nil)
+	if errors.Cause(err) != ErrorResponseTimeout {
+		t.Errorf("Expected %v, got %v", ErrorResponseTimeout, err)
+	}
+
+	lateResponses(c, stop())
+	assertNoRequestState(t, c)
+}
+
+func TestReaperExpiresOrphanedCursor(t *testing.T) {
+	timeout := 50 * time.Millisecond
+	c := newClient()
+	SetResponseTimeout(timeout)(c)
+	stop := drainRequests(t, c)
+
+	cursor, err := c.executeRequestCursorCtx(context.Background(), "g.V()", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.saveResponse(Response{RequestID: cursor.ID, Status: Status{Code: StatusPartialContent}, Result: Result{Data: []byte("chunk1")}}, nil)
+
+	// recent activity, so not yet reaped
+	c.reap(time.Now())
+	if _, ok := c.inflight.Load(cursor.ID); !ok {
+		t.Fatal("Expected cursor to remain in flight")
+	}
+
+	c.reap(time.Now().Add(2 * timeout))
+	lateResponses(c, stop())
+	assertNoRequestState(t, c)
+
+	if _, _, err = c.retrieveNextResponseCtx(context.Background(), cursor); err != ErrorResponseTimeout {
+		t.Errorf("Expected %v, got %v", ErrorResponseTimeout, err)
+	}
+}
+
+func TestReaperExpiresCancelledCursor(t *testing.T) {
+	c := newClient() // no response timeout
+	stop := drainRequests(t, c)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cursor, err := c.executeRequestCursorCtx(ctx, "g.V()", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := c.executeRequestCursorCtx(context.Background(), "g.E()", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.saveResponse(Response{RequestID: cursor.ID, Status: Status{Code: StatusPartialContent}, Result: Result{Data: []byte("chunk1")}}, nil)
+
+	// in flight until its context is done, however long it is inactive
+	c.reap(time.Now().Add(expiredRetention))
+	if _, ok := c.inflight.Load(cursor.ID); !ok {
+		t.Fatal("Expected cursor to remain in flight")
+	}
+
+	// abandoned: its context is cancelled, but it is neither read nor closed
+	cancel()
+	c.reap(time.Now())
+	if !c.isExpired(cursor.ID) {
+		t.Error("Expected the cancelled cursor to be expired")
+	}
+	if _, ok := c.inflight.Load(other.ID); !ok {
+		t.Error("Expected the other cursor to remain in flight")
+	}
+
+	c.closeCursor(other)
+	lateResponses(c, stop())
+	assertNoRequestState(t, c)
+}
+
+func TestReaperForgetsExpiredCursor(t *testing.T) {
+	c := newClient()
+	SetResponseTimeout(time.Minute)(c)
+	stop := drainRequests(t, c)
+
+	cursor, err := c.executeRequestCursorCtx(context.Background(), "g.V()", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.reap(time.Now().Add(2 * time.Minute))
+	if _, _, err = c.retrieveNextResponseCtx(context.Background(), cursor); err != ErrorResponseTimeout {
+		t.Errorf("Expected %v, got %v", ErrorResponseTimeout, err)
+	}
+	lateResponses(c, stop())
+	assertNoRequestState(t, c)
+
+	// once forgotten, reading the cursor fails rather than returning no results (and no eof) forever
+	c.reap(time.Now().Add(expiredRetention + time.Minute))
+	if c.isExpired(cursor.ID) {
+		t.Fatal("Expected the expired cursor to be forgotten")
+	}
+	if _, _, err = c.retrieveNextResponseCtx(context.Background(), cursor); err != ErrorUnknownCursor {
+		t.Errorf("Expected %v, got %v", ErrorUnknownCursor, err)
+	}
+	vc := &ValueCursor{Cursor: *cursor, client: c}
+	if _, _, err = vc.NextValues(context.Background()); errors.Cause(err) != ErrorUnknownCursor {
+		t.Errorf("Expected %v, got %v", ErrorUnknownCursor, err)
+	}
+}
@@ -0,0 +1,209 @@
+package gremgo
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/gremgo-neptune/traversal"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrorNoQueryRegistry = errors.New("no query registry set (see SetQueryRegistry)")
+	ErrorUnknownQuery    = errors.New("unknown named query")
+	ErrorPlaceholder     = errors.New("invalid placeholder")
+	ErrorQueryParams     = errors.New("parameters do not match the placeholders of the query")
+)
+
+// queryFileExt is the extension of the script files of a QueryRegistry
+const queryFileExt = ".groovy"
+
+// NamedQuery is a query of a QueryRegistry, whose script has placeholders for its parameters
+// (`$` and the parameter name, e.g. `g.V($id)`) which are substituted as literals
+type NamedQuery struct {
+	Name         string
+	Script       string
+	Placeholders []string // the parameter names, sorted
+}
+
+// QueryStats are the metrics of the calls of a named query
+type QueryStats struct {
+	Calls  int64         // the number of calls
+	Errors int64         // the number of calls which failed
+	Total  time.Duration // the total duration of the calls
+	Max    time.Duration // the duration of the slowest call
+}
+
+// QueryRegistry is a set of named queries, loaded from script files (see LoadQueryRegistry),
+// with metrics of their calls. It is safe for concurrent use.
+type QueryRegistry struct {
+	queries map[string]NamedQuery
+	mu      sync.Mutex
+	stats   map[string]QueryStats
+}
+
+// LoadQueryRegistry loads the named queries from the `.groovy` files of fsys (and of its subdirectories), each
+// named by its path without the extension (e.g. "findDataset" or "datasets/find"), validating their placeholders
+func LoadQueryRegistry(fsys fs.FS) (*QueryRegistry, error) {
+	r := &QueryRegistry{queries: make(map[string]NamedQuery), stats: make(map[string]QueryStats)}
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || path.Ext(p) != queryFileExt {
+			return nil
+		}
+		script, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		q, err := NewNamedQuery(strings.TrimSuffix(p, queryFileExt), string(script))
+		if err != nil {
+			return errors.Wrap(err, p)
+		}
+		r.queries[q.Name] = q
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// LoadQueryRegistryDir loads the named queries from the `.groovy` files of dir (see LoadQueryRegistry)
+func LoadQueryRegistryDir(dir string) (*QueryRegistry, error) {
+	return LoadQueryRegistry(os.DirFS(dir))
+}
+
+// NewNamedQuery returns the query with its placeholders, failing with ErrorPlaceholder for a `$` (outside strings
+// and comments) which is not followed by a valid parameter name
+func NewNamedQuery(name, script string) (q NamedQuery, err error) {
+	q = NamedQuery{Name: name, Script: script}
+	seen := make(map[string]bool)
+	replaceIdentifiers(script, func(ident string, start, end int) (string, bool) {
+		if !strings.HasPrefix(ident, "$") || err != nil {
+			return "", false
+		}
+		param := ident[1:]
+		if !bindingNameRegexp.MatchString(param) {
+			err = errors.Wrapf(ErrorPlaceholder, "%q at offset %d", ident, start)
+		} else if !seen[param] {
+			seen[param] = true
+			q.Placeholders = append(q.Placeholders, param)
+		}
+		return "", false
+	})
+	sort.Strings(q.Placeholders)
+	return
+}
+
+// Interpolate returns the script with each placeholder replaced by its parameter as a Gremlin-Groovy literal,
+// failing with ErrorQueryParams unless params has exactly the parameters of the placeholders
+func (q NamedQuery) Interpolate(params Bindings) (string, error) {
+	literals := make(map[string]string, len(params))
+	for _, param := range q.Placeholders {
+		val, ok := params[param]
+		if !ok {
+			return "", errors.Wrapf(ErrorQueryParams, "%s: missing %q", q.Name, param)
+		}
+		lit, err := traversal.Literal(val)
+		if err != nil {
+			return "", errors.Wrapf(err, "%s: parameter %q", q.Name, param)
+		}
+		literals["$"+param] = lit
+	}
+	if len(params) != len(q.Placeholders) {
+		for param := range params {
+			if _, ok := literals["$"+param]; !ok {
+				return "", errors.Wrapf(ErrorQueryParams, "%s: unknown %q", q.Name, param)
+			}
+		}
+	}
+	return replaceIdentifiers(q.Script, func(ident string, start, end int) (string, bool) {
+		lit, ok := literals[ident]
+		return lit, ok
+	}), nil
+}
+
+// Query returns the named query
+func (r *QueryRegistry) Query(name string) (NamedQuery, error) {
+	q, ok := r.queries[name]
+	if !ok {
+		return q, errors.Wrapf(ErrorUnknownQuery, "%q", name)
+	}
+	return q, nil
+}
+
+// Names returns the names of the queries, sorted
+func (r *QueryRegistry) Names() []string {
+	names := make([]string, 0, len(r.queries))
+	for name := range r.queries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Stats returns the metrics of the calls of each query which has been called
+func (r *QueryRegistry) Stats() map[string]QueryStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats := make(map[string]QueryStats, len(r.stats))
+	for name, s := range r.stats {
+		stats[name] = s
+	}
+	return stats
+}
+
+// record adds a call of the query name, which took d, to its metrics
+func (r *QueryRegistry) record(name string, d time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.stats[name]
+	s.Calls++
+	if err != nil {
+		s.Errors++
+	}
+	s.Total += d
+	if d > s.Max {
+		s.Max = d
+	}
+	r.stats[name] = s
+}
+
+// Named runs the query name of the client's QueryRegistry (see SetQueryRegistry) with params substituted for its
+// placeholders, and returns the decoded results
+func (c *Client) Named(ctx context.Context, name string, params Bindings) (res List, err error) {
+	if c.queries == nil {
+		return nil, ErrorNoQueryRegistry
+	}
+	var q NamedQuery
+	if q, err = c.queries.Query(name); err != nil {
+		return
+	}
+	start := time.Now()
+	defer func() {
+		c.queries.record(name, time.Since(start), err)
+	}()
+	var query string
+	if query, err = q.Interpolate(params); err != nil {
+		return
+	}
+	return c.QueryBindingsCtx(ctx, query, nil, nil)
+}
+
+// Named runs the query name of the QueryRegistry of the pool's clients (see Client.Named)
+func (p *Pool) Named(ctx context.Context, name string, params Bindings) (res List, err error) {
+	var pc *conn
+	if pc, err = p.connCtx(ctx); err != nil {
+		return nil, errors.Wrap(err, "Named: Failed p.connCtx")
+	}
+	defer p.putConn(pc, err)
+	return pc.Client.Named(ctx, name, params)
+}
@@ -0,0 +1,97 @@
+package gremgo
+
+import (
+	"context"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var testQueries = fstest.MapFS{
+	"findDataset.groovy":       {Data: []byte("// finds a dataset by $id (in a comment)\ng.V($id).hasLabel('dataset').has('state', $state).has('note', '$notParam')")},
+	"datasets/count.groovy":    {Data: []byte("g.V().hasLabel('dataset').count()")},
+	"datasets/README.md":       {Data: []byte("not a query $")},
+	"editions/latest.groovy":   {Data: []byte("g.V($id).out('edition').order().by('released', desc).limit($n)")},
+	"editions/notquery.groovy": {Mode: fs.ModeDir}, // a directory
+}
+
+func TestLoadQueryRegistry(t *testing.T) {
+	r, err := LoadQueryRegistry(testQueries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := r.Names(); !reflect.DeepEqual(names, []string{"datasets/count", "editions/latest", "findDataset"}) {
+		t.Errorf("Unexpected names %v", names)
+	}
+	q, err := r.Query("findDataset")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(q.Placeholders, []string{"id", "state"}) {
+		t.Errorf("Expected placeholders [id state], got %v", q.Placeholders)
+	}
+	if _, err = r.Query("missing"); errors.Cause(err) != ErrorUnknownQuery {
+		t.Errorf("Expected %v, got %v", ErrorUnknownQuery, err)
+	}
+
+	for _, script := range []string{"g.V($)", "g.V(${id})", "g.V($1)"} {
+		bad := fstest.MapFS{"bad.groovy": {Data: []byte(script)}}
+		if _, err = LoadQueryRegistry(bad); errors.Cause(err) != ErrorPlaceholder {
+			t.Errorf("%s: expected %v, got %v", script, ErrorPlaceholder, err)
+		}
+	}
+}
+
+func TestNamedQueryInterpolate(t *testing.T) {
+	q, err := NewNamedQuery("latest", "g.V($id).out('edition').limit($n).has('x', \"$n\")")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := q.Interpolate(Bindings{"id": "it's", "n": 2})
+	if expected := `g.V('it\'s').out('edition').limit(2).has('x', "$n")`; err != nil || s != expected {
+		t.Errorf("Expected %s, got %s %v", expected, s, err)
+	}
+
+	for _, params := range []Bindings{{"id": "a"}, {"id": "a", "n": 1, "extra": 2}} {
+		if _, err = q.Interpolate(params); errors.Cause(err) != ErrorQueryParams {
+			t.Errorf("%v: expected %v, got %v", params, ErrorQueryParams, err)
+		}
+	}
+}
+
+func TestNamed(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c := newChunksClient()
+	if _, err := c.Named(ctx, "findDataset", nil); err != ErrorNoQueryRegistry {
+		t.Errorf("Expected %v, got %v", ErrorNoQueryRegistry, err)
+	}
+
+	r, err := LoadQueryRegistry(testQueries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetQueryRegistry(r)(c)
+	sent := sentRequest(t, c)
+	if _, err = c.Named(ctx, "editions/latest", Bindings{"id": "cpih", "n": 1}); err != nil {
+		t.Fatal(err)
+	}
+	req := <-sent
+	if q := req.Args["gremlin"]; q != "g.V('cpih').out('edition').order().by('released', desc).limit(1)" {
+		t.Errorf("Unexpected query %v", q)
+	}
+	if _, err = c.Named(ctx, "editions/latest", Bindings{"id": "cpih"}); errors.Cause(err) != ErrorQueryParams {
+		t.Errorf("Expected %v, got %v", ErrorQueryParams, err)
+	}
+
+	stats := r.Stats()["editions/latest"]
+	if stats.Calls != 2 || stats.Errors != 1 || stats.Max <= 0 || stats.Total < stats.Max {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+	assertNoRequestState(t, c)
+}
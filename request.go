@@ -5,7 +5,9 @@ import (
 	"encoding/base64"
 	"encoding/json"
 
+	"github.com/ONSdigital/gremgo-neptune/traversal"
 	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
 )
 
 const mimeTypeStr = "application/vnd.gremlin-v3.0+json"
@@ -28,7 +30,7 @@ type request struct {
 }
 
 // prepareRequest packages a query and binding into the format that Gremlin Server accepts
-func prepareRequest(query string, bindings, rebindings map[string]string) (req request, id string, err error) {
+func prepareRequest(query string, bindings Bindings, rebindings map[string]string) (req request, id string, err error) {
 	var uuID uuid.UUID
 	if uuID, err = uuid.NewV4(); err != nil {
 		return
@@ -43,13 +45,32 @@ func prepareRequest(query string, bindings, rebindings map[string]string) (req r
 	req.Args["language"] = "gremlin-groovy"
 	req.Args["gremlin"] = query
 	if len(bindings) > 0 || len(rebindings) > 0 {
-		req.Args["bindings"] = bindings
+		if req.Args["bindings"], err = encodeBindings(bindings); err != nil {
+			return
+		}
 		req.Args["rebindings"] = rebindings
 	}
 
 	return
 }
 
+// encodeBindings returns the bindings as GraphSON values (strings being sent as they are)
+func encodeBindings(bindings Bindings) (map[string]interface{}, error) {
+	encoded := make(map[string]interface{}, len(bindings))
+	for name, val := range bindings {
+		if s, ok := val.(string); ok {
+			encoded[name] = s
+			continue
+		}
+		v, err := traversal.GraphSONValue(val)
+		if err != nil {
+			return nil, errors.Wrapf(err, "binding %q", name)
+		}
+		encoded[name] = v
+	}
+	return encoded, nil
+}
+
 //prepareAuthRequest creates a ws request for Gremlin Server
 func prepareAuthRequest(requestID string, username string, password string) (req request, err error) {
 	req.RequestID = requestID
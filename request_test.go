@@ -9,7 +9,7 @@ import (
 // TestRequestPreparation tests the ability to package a query and a set of bindings into a request struct for further manipulation
 func TestRequestPreparation(t *testing.T) {
 	query := "g.V(x)"
-	bindings := map[string]string{"x": "10"}
+	bindings := Bindings{"x": "10"}
 	rebindings := map[string]string{}
 	req, id, err := prepareRequest(query, bindings, rebindings)
 	if err != nil {
@@ -22,7 +22,7 @@ func TestRequestPreparation(t *testing.T) {
 		Processor: "",
 		Args: map[string]interface{}{
 			"gremlin":    query,
-			"bindings":   bindings,
+			"bindings":   map[string]interface{}{"x": "10"},
 			"language":   "gremlin-groovy",
 			"rebindings": rebindings,
 		},
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/pkg/errors"
 )
@@ -22,6 +23,79 @@ const (
 	StatusServerSerializationError = 599
 )
 
+// Sentinel errors for unsuccessful response status codes, a *ServerError matches the one for its Code using errors.Is
+var (
+	ErrUnauthorized            = errors.New("unauthorized")
+	ErrAuthenticate            = errors.New("authenticate")
+	ErrMalformedRequest        = errors.New("malformed request")
+	ErrInvalidRequestArguments = errors.New("invalid request arguments")
+	ErrServerError             = errors.New("server error")
+	ErrScriptEvaluation        = errors.New("script evaluation error")
+	ErrServerTimeout           = errors.New("server timeout")
+	ErrServerSerialization     = errors.New("server serialization error")
+	ErrUnknownStatus           = errors.New("unknown error")
+)
+
+var statusErrors = map[int]error{
+	StatusUnauthorized:             ErrUnauthorized,
+	StatusAuthenticate:             ErrAuthenticate,
+	StatusMalformedRequest:         ErrMalformedRequest,
+	StatusInvalidRequestArguments:  ErrInvalidRequestArguments,
+	StatusServerError:              ErrServerError,
+	StatusScriptEvaluationError:    ErrScriptEvaluation,
+	StatusServerTimeout:            ErrServerTimeout,
+	StatusServerSerializationError: ErrServerSerialization,
+}
+
+// ServerError is the error for a response from Gremlin Server with an unsuccessful status
+type ServerError struct {
+	Code       int
+	Message    string
+	Attributes map[string]interface{}
+	RequestID  string
+	// Exceptions are the server-side exception classes (outermost first), from the `exceptions` attribute
+	Exceptions []string
+	// StackTrace is the server-side stack trace, from the `stackTrace` attribute
+	StackTrace string
+}
+
+func newServerError(r *Response) *ServerError {
+	e := &ServerError{
+		Code:       r.Status.Code,
+		Message:    r.Status.Message,
+		Attributes: r.Status.Attributes,
+		RequestID:  r.RequestID,
+	}
+	if exceptions, ok := r.Status.Attributes["exceptions"].([]interface{}); ok {
+		for _, exception := range exceptions {
+			if exceptionStr, ok := exception.(string); ok {
+				e.Exceptions = append(e.Exceptions, exceptionStr)
+			}
+		}
+	}
+	if stackTrace, ok := r.Status.Attributes["stackTrace"].(string); ok {
+		e.StackTrace = stackTrace
+	}
+	return e
+}
+
+// Error returns the status (upper-cased) and message of the response
+func (e *ServerError) Error() string {
+	return fmt.Sprintf("%s - Response Message: %s", strings.ToUpper(e.status().Error()), e.Message)
+}
+
+// Is allows errors.Is to match the ServerError against the sentinel error for its Code
+func (e *ServerError) Is(target error) bool {
+	return target == e.status()
+}
+
+func (e *ServerError) status() error {
+	if err, ok := statusErrors[e.Code]; ok {
+		return err
+	}
+	return ErrUnknownStatus
+}
+
 // Status struct is used to hold properties returned from requests to the gremlin server
 type Status struct {
 	Message    string                 `json:"message"`
@@ -65,7 +139,10 @@ func (c *Client) handleResponse(msg []byte) (err error) {
 	var resp Response
 	resp, err = marshalResponse(msg)
 	if resp.Status.Code == StatusAuthenticate { //Server request authentication
-		return c.authenticate(resp.RequestID)
+		if err = c.authenticate(resp.RequestID); err != nil {
+			c.saveResponse(resp, err) // fail the request, rather than leave it waiting
+		}
+		return
 	}
 	c.saveResponse(resp, err)
 	return
@@ -83,9 +160,19 @@ func marshalResponse(msg []byte) (resp Response, err error) {
 }
 
 // saveResponse makes the response (and its err) available for retrieval by the requester.
+// Responses for expired requests are discarded.
 // Mutexes are used for thread safety.
 func (c *Client) saveResponse(resp Response, err error) {
 	c.Lock()
+	if c.isExpired(resp.RequestID) {
+		c.Unlock()
+		return
+	}
+	if sizeErr := c.addResponseSize(resp); sizeErr != nil {
+		c.Unlock()
+		c.abortTooLarge(resp.RequestID, sizeErr)
+		return
+	}
 	var newdata []interface{}
 	existingData, ok := c.results.Load(resp.RequestID) // Retrieve old data container (for requests with multiple responses)
 	if ok {
@@ -95,16 +182,24 @@ func (c *Client) saveResponse(resp Response, err error) {
 		newdata = append(newdata, resp)
 	}
 	c.results.Store(resp.RequestID, newdata) // Add new data to buffer for future retrieval
+	c.touchRequest(resp.RequestID)
+
+	var chunkNotifier, respNotifier interface{}
+	if resp.Status.Code == StatusPartialContent {
+		chunkNotifier, ok = c.chunkNotifier.Load(resp.RequestID)
+	} else {
+		respNotifier, _ = c.responseNotifier.LoadOrStore(resp.RequestID, make(chan error, 1))
+	}
 	c.Unlock()
 
 	// err is from marshalResponse (json.Unmarshal), but is ignored when Code==statusPartialContent
-	if resp.Status.Code == StatusPartialContent {
-		if chunkNotifier, ok := c.chunkNotifier.Load(resp.RequestID); ok {
-			chunkNotifier.(chan bool) <- true
+	if chunkNotifier != nil {
+		c.notifyChunk(resp.RequestID, chunkNotifier.(chan bool))
+	} else if respNotifier != nil {
+		select {
+		case respNotifier.(chan error) <- err:
+		default: // a final response has already been notified (duplicate response), do not block
 		}
-	} else {
-		respNotifier, _ := c.responseNotifier.LoadOrStore(resp.RequestID, make(chan error, 1))
-		respNotifier.(chan error) <- err
 	}
 }
 
@@ -135,22 +230,59 @@ func (c *Client) getCurrentResults(id string) (data []Response) {
 	return
 }
 
+// takePartialResults removes and returns the leading partial responses for the request,
+// leaving any final response for retrieval once its notifier fires (must be locked)
+func (c *Client) takePartialResults(id string) (data []Response) {
+	data = c.getCurrentResults(id)
+	for i := range data {
+		if data[i].Status.Code != StatusPartialContent {
+			remaining := make([]interface{}, 0, len(data)-i)
+			for _, resp := range data[i:] {
+				remaining = append(remaining, resp)
+			}
+			c.results.Store(id, remaining)
+			return data[:i]
+		}
+	}
+	c.deleteResponse(id)
+	return
+}
+
 func (c *Client) cleanResults(id string, respNotifier chan error, chunkNotifier chan bool) {
 	if respNotifier == nil {
 		return
 	}
 	c.responseNotifier.Delete(id)
+	c.inflight.Delete(id)
+	c.responseSizes.Delete(id)
 	close(respNotifier)
 	if chunkNotifier != nil {
 		close(chunkNotifier)
 		c.chunkNotifier.Delete(id)
+		c.releaseChunks(id)
 	}
 	c.deleteResponse(id)
 }
 
+// withResponseTimeout returns ctx bounded by the client's response timeout (if set)
+func (c *Client) withResponseTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.responseTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.responseTimeout)
+}
+
 // retrieveResponseCtx retrieves the response saved by saveResponse.
+// If ctx is done (or the response timeout passes) first, the request is expired.
 func (c *Client) retrieveResponseCtx(ctx context.Context, id string) (data []Response, err error) {
-	respNotifier, _ := c.responseNotifier.Load(id)
+	respNotifier, ok := c.responseNotifier.Load(id)
+	if !ok {
+		return nil, ErrorResponseTimeout
+	}
+
+	timeoutCtx, cancel := c.withResponseTimeout(ctx)
+	defer cancel()
+
 	select {
 	case err = <-respNotifier.(chan error):
 		defer c.cleanResults(id, respNotifier.(chan error), nil)
@@ -158,19 +290,30 @@ func (c *Client) retrieveResponseCtx(ctx context.Context, id string) (data []Res
 			return
 		}
 		data = c.getCurrentResults(id)
-	case <-ctx.Done():
-		err = ctx.Err()
+	case <-timeoutCtx.Done():
+		c.expireRequest(id)
+		if err = ctx.Err(); err == nil {
+			err = ErrorResponseTimeout
+		}
 	}
 	return
 }
 
 // retrieveNextResponseCtx retrieves the current response (may be empty!) saved by saveResponse,
 //  `done` is true when the results are complete (eof)
+// If the response timeout passes before the next response, the request is expired,
+// but as the cursor may be read again, it is not expired when ctx is done.
+// The error is ErrorUnknownCursor for a cursor which this client does not know (e.g. one already read to the end).
 func (c *Client) retrieveNextResponseCtx(ctx context.Context, cursor *Cursor) (data []Response, done bool, err error) {
 	c.Lock()
 	respNotifier, ok := c.responseNotifier.Load(cursor.ID)
 	c.Unlock()
 	if respNotifier == nil || !ok {
+		if c.isExpired(cursor.ID) {
+			err = ErrorResponseTimeout
+		} else {
+			err = ErrorUnknownCursor
+		}
 		return
 	}
 
@@ -179,8 +322,21 @@ func (c *Client) retrieveNextResponseCtx(ctx context.Context, cursor *Cursor) (d
 		chunkNotifier = chunkNotifierInterface.(chan bool)
 	}
 
+	timeoutCtx, cancel := c.withResponseTimeout(ctx)
+	defer cancel()
+
 	select {
 	case err = <-respNotifier.(chan error):
+		if err != nil {
+			c.Lock()
+			data = c.takePartialResults(cursor.ID)
+			c.Unlock()
+			if len(data) > 0 {
+				// return the partial responses which arrived before the error, leaving the error for the next call
+				respNotifier.(chan error) <- err
+				return data, false, nil
+			}
+		}
 		defer c.cleanResults(cursor.ID, respNotifier.(chan error), chunkNotifier)
 		if err != nil {
 			return
@@ -189,11 +345,23 @@ func (c *Client) retrieveNextResponseCtx(ctx context.Context, cursor *Cursor) (d
 		done = true
 	case <-chunkNotifier:
 		c.Lock()
-		data = c.getCurrentResults(cursor.ID)
-		c.deleteResponse(cursor.ID)
+		data = c.takePartialResults(cursor.ID)
+		c.touchRequest(cursor.ID)
+		c.releaseChunks(cursor.ID)
+		// all the buffered partial responses have been taken, so discard their notifications (freeing the buffer)
+		for drained := false; !drained; {
+			select {
+			case <-chunkNotifier:
+			default:
+				drained = true
+			}
+		}
 		c.Unlock()
-	case <-ctx.Done():
-		err = ctx.Err()
+	case <-timeoutCtx.Done():
+		if err = ctx.Err(); err == nil {
+			c.expireRequest(cursor.ID)
+			err = ErrorResponseTimeout
+		}
 	}
 
 	return
@@ -205,28 +373,17 @@ func (c *Client) deleteResponse(id string) {
 	return
 }
 
-// detectError detects any possible errors in responses from Gremlin Server and generates an error for each code
+// detectError detects any possible errors in responses from Gremlin Server and generates a *ServerError for each code
+// (or a *NeptuneError, when the status message contains one)
 func (r *Response) detectError() (err error) {
 	switch r.Status.Code {
 	case StatusSuccess, StatusNoContent, StatusPartialContent:
-	case StatusUnauthorized:
-		err = fmt.Errorf("UNAUTHORIZED - Response Message: %s", r.Status.Message)
-	case StatusAuthenticate:
-		err = fmt.Errorf("AUTHENTICATE - Response Message: %s", r.Status.Message)
-	case StatusMalformedRequest:
-		err = fmt.Errorf("MALFORMED REQUEST - Response Message: %s", r.Status.Message)
-	case StatusInvalidRequestArguments:
-		err = fmt.Errorf("INVALID REQUEST ARGUMENTS - Response Message: %s", r.Status.Message)
-	case StatusServerError:
-		err = fmt.Errorf("SERVER ERROR - Response Message: %s", r.Status.Message)
-	case StatusScriptEvaluationError:
-		err = fmt.Errorf("SCRIPT EVALUATION ERROR - Response Message: %s", r.Status.Message)
-	case StatusServerTimeout:
-		err = fmt.Errorf("SERVER TIMEOUT - Response Message: %s", r.Status.Message)
-	case StatusServerSerializationError:
-		err = fmt.Errorf("SERVER SERIALIZATION ERROR - Response Message: %s", r.Status.Message)
 	default:
-		err = fmt.Errorf("UNKNOWN ERROR - Response Message: %s", r.Status.Message)
+		serverErr := newServerError(r)
+		if neptuneErr := parseNeptuneError(serverErr); neptuneErr != nil {
+			return neptuneErr
+		}
+		err = serverErr
 	}
 	return
 }
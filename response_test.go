@@ -1,6 +1,9 @@
 package gremgo
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"log"
 	"reflect"
 	"testing"
@@ -234,3 +237,118 @@ func TestResponseErrorDetection(t *testing.T) {
 		}
 	}
 }
+
+// TestServerError tests that error statuses are detected as a *ServerError matching their sentinel error
+func TestServerError(t *testing.T) {
+	sentinels := map[int]error{
+		401:  ErrUnauthorized,
+		407:  ErrAuthenticate,
+		498:  ErrMalformedRequest,
+		499:  ErrInvalidRequestArguments,
+		500:  ErrServerError,
+		597:  ErrScriptEvaluation,
+		598:  ErrServerTimeout,
+		599:  ErrServerSerialization,
+		3434: ErrUnknownStatus,
+	}
+	for code, sentinel := range sentinels {
+		dummyResponse := Response{
+			RequestID: "req-id",
+			Status:    Status{Code: code, Message: "BOOM"},
+		}
+		err := dummyResponse.detectError()
+		if !errors.Is(err, sentinel) {
+			t.Errorf("Expected code %d to be %v, got %v", code, sentinel, err)
+		}
+		if errors.Is(err, ErrServerTimeout) != (code == StatusServerTimeout) {
+			t.Errorf("Expected code %d to only match ErrServerTimeout when %d", code, StatusServerTimeout)
+		}
+		var serverErr *ServerError
+		if !errors.As(err, &serverErr) {
+			t.Fatalf("Expected code %d to be a *ServerError, got %T", code, err)
+		}
+		if serverErr.Code != code || serverErr.Message != "BOOM" || serverErr.RequestID != "req-id" {
+			t.Errorf("Unexpected ServerError fields for code %d: %+v", code, serverErr)
+		}
+	}
+}
+
+var dummyScriptEvaluationErrorResponse = []byte(`{"requestId":"1d6d02bd-8e56-421d-9438-3bd6d0079ff1",
+ "status":{"code":597,"message":"No such property: x for class: Script1",
+  "attributes":{"exceptions":["groovy.lang.MissingPropertyException"],"stackTrace":"groovy.lang.MissingPropertyException: No such property"}},
+ "result":{"data":null,"meta":{}}}`)
+
+// TestServerErrorAttributes tests that a ServerError retains the attributes of the response, through to the requester
+func TestServerErrorAttributes(t *testing.T) {
+	c := newClient()
+	stop := drainRequests(t, c)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := c.executeRequestCtx(context.Background(), "g.V(x)", nil, nil)
+		errCh <- err
+	}()
+
+	var id string
+	for id == "" {
+		c.responseNotifier.Range(func(key, val interface{}) bool {
+			id = key.(string)
+			return false
+		})
+	}
+	msg := bytes.Replace(dummyScriptEvaluationErrorResponse, []byte("1d6d02bd-8e56-421d-9438-3bd6d0079ff1"), []byte(id), 1)
+	if err := c.handleResponse(msg); err == nil {
+		t.Error("Expected handleResponse to return the ServerError")
+	}
+
+	err := <-errCh
+	if !errors.Is(err, ErrScriptEvaluation) {
+		t.Fatalf("Expected %v, got %v", ErrScriptEvaluation, err)
+	}
+	var serverErr *ServerError
+	if !errors.As(err, &serverErr) {
+		t.Fatalf("Expected a *ServerError, got %T", err)
+	}
+	if serverErr.RequestID != id {
+		t.Errorf("Expected RequestID %q, got %q", id, serverErr.RequestID)
+	}
+	if !reflect.DeepEqual(serverErr.Exceptions, []string{"groovy.lang.MissingPropertyException"}) {
+		t.Errorf("Unexpected Exceptions: %v", serverErr.Exceptions)
+	}
+	if serverErr.StackTrace == "" || serverErr.Attributes["stackTrace"] == nil {
+		t.Errorf("Expected the stack trace to be retained: %+v", serverErr)
+	}
+}
+
+// FuzzHandleResponse tests that no message from the server can panic (or block) the client's workers
+func FuzzHandleResponse(f *testing.F) {
+	for _, seed := range [][]byte{
+		dummySuccessfulResponse,
+		dummyNeedAuthenticationResponse,
+		dummyPartialResponse1,
+		dummyPartialResponse2,
+		dummyScriptEvaluationErrorResponse,
+		dummyNeptuneConcurrentModification,
+		[]byte(`{"requestId":"id","status":{"code":206},"result":{"data":{"@type":"g:List","@value":[]}}}`),
+		[]byte(`{"requestId":"id","status":{"code":200,"attributes":{"exceptions":[1,null]}},"result":{"data":null}}`),
+		[]byte(`{}`),
+		[]byte(`null`),
+		[]byte(``),
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, msg []byte) {
+		c := newClient()
+		c.conn = &dialerMock{getAuthFunc: func() (*auth, error) { return nil, ErrorNoAuthCredentials }}
+		c.handleResponse(msg)
+		c.handleResponse(msg) // duplicate
+
+		resp, _ := marshalResponse(msg)
+		c.chunkNotifier.Store(resp.RequestID, make(chan bool, 10))
+		c.handleResponse(msg)
+		if _, _, err := c.retrieveNextResponseCtx(context.Background(), &Cursor{ID: resp.RequestID}); err == nil {
+			c.deserializeResponseToVertices(c.getCurrentResults(resp.RequestID))
+		}
+	})
+}
@@ -9,6 +9,7 @@ import (
 )
 
 var (
+	lockRetrieverMockcloseCursor             sync.RWMutex
 	lockRetrieverMockretrieveNextResponseCtx sync.RWMutex
 )
 
@@ -22,6 +23,9 @@ var _ Retriever = &RetrieverMock{}
 //
 //         // make and configure a mocked Retriever
 //         mockedRetriever := &RetrieverMock{
+//             closeCursorFunc: func(cursor *Cursor)  {
+// 	               panic("mock out the closeCursor method")
+//             },
 //             retrieveNextResponseCtxFunc: func(ctx context.Context, cursor *Cursor) ([]Response, bool, error) {
 // 	               panic("mock out the retrieveNextResponseCtx method")
 //             },
@@ -32,11 +36,19 @@ var _ Retriever = &RetrieverMock{}
 //
 //     }
 type RetrieverMock struct {
+	// closeCursorFunc mocks the closeCursor method.
+	closeCursorFunc func(cursor *Cursor)
+
 	// retrieveNextResponseCtxFunc mocks the retrieveNextResponseCtx method.
 	retrieveNextResponseCtxFunc func(ctx context.Context, cursor *Cursor) ([]Response, bool, error)
 
 	// calls tracks calls to the methods.
 	calls struct {
+		// closeCursor holds details about calls to the closeCursor method.
+		closeCursor []struct {
+			// Cursor is the cursor argument value.
+			Cursor *Cursor
+		}
 		// retrieveNextResponseCtx holds details about calls to the retrieveNextResponseCtx method.
 		retrieveNextResponseCtx []struct {
 			// Ctx is the ctx argument value.
@@ -47,6 +59,37 @@ type RetrieverMock struct {
 	}
 }
 
+// closeCursor calls closeCursorFunc.
+func (mock *RetrieverMock) closeCursor(cursor *Cursor) {
+	if mock.closeCursorFunc == nil {
+		panic("RetrieverMock.closeCursorFunc: method is nil but Retriever.closeCursor was just called")
+	}
+	callInfo := struct {
+		Cursor *Cursor
+	}{
+		Cursor: cursor,
+	}
+	lockRetrieverMockcloseCursor.Lock()
+	mock.calls.closeCursor = append(mock.calls.closeCursor, callInfo)
+	lockRetrieverMockcloseCursor.Unlock()
+	mock.closeCursorFunc(cursor)
+}
+
+// closeCursorCalls gets all the calls that were made to closeCursor.
+// Check the length with:
+//     len(mockedRetriever.closeCursorCalls())
+func (mock *RetrieverMock) closeCursorCalls() []struct {
+	Cursor *Cursor
+} {
+	var calls []struct {
+		Cursor *Cursor
+	}
+	lockRetrieverMockcloseCursor.RLock()
+	calls = mock.calls.closeCursor
+	lockRetrieverMockcloseCursor.RUnlock()
+	return calls
+}
+
 // retrieveNextResponseCtx calls retrieveNextResponseCtxFunc.
 func (mock *RetrieverMock) retrieveNextResponseCtx(ctx context.Context, cursor *Cursor) ([]Response, bool, error) {
 	if mock.retrieveNextResponseCtxFunc == nil {
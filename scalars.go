@@ -0,0 +1,194 @@
+package gremgo
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+)
+
+// TypeDecoder decodes the `@value` of a GraphSON typed value (e.g. `1` for `{"@type":"g:Int32","@value":1}`)
+type TypeDecoder func(value json.RawMessage) (interface{}, error)
+
+var (
+	scalarDecodersMu sync.RWMutex
+	scalarDecoders   = map[string]TypeDecoder{
+		"g:Int32":       decodeInt32,
+		"g:Int64":       decodeInt64,
+		"g:Double":      decodeDouble,
+		"g:Float":       decodeFloat,
+		"g:Date":        decodeDate,
+		"g:Timestamp":   decodeDate,
+		"g:UUID":        decodeUUID,
+		"g:Class":       decodeString,
+		"g:BigDecimal":  decodeBigDecimal,
+		"gx:BigDecimal": decodeBigDecimal,
+		"g:BigInteger":  decodeBigInteger,
+		"gx:BigInteger": decodeBigInteger,
+		"gx:Byte":       decodeByte,
+		"gx:Int16":      decodeInt16,
+		"gx:Char":       decodeString,
+	}
+)
+
+// RegisterType sets the decoder for the scalar GraphSON type `graphsonType` (e.g. "g:UUID", or a custom type),
+// replacing any existing decoder for that type. The collection and element types (e.g. g:List, g:Vertex) cannot be
+// replaced.
+//
+// The registered decoders are used by DecodeGraphSON, and so for the results of e.g. QueryCtx, ValueCursor,
+// QueryChunksCtx, GetCount and GetProperties, and for the property values decoded by DecodeVertices, DecodeVertex
+// and DecodeEdges (e.g. for GetIntoCtx). They are not used for the graphson.Vertex and graphson.Edge results of
+// e.g. GetCtx, ReadCursorCtx and GetE, nor for GetStringList, which are decoded by the graphson package.
+//
+// The built-in scalar types decode as:
+//
+//	g:Int32 int32, g:Int64 int64, g:Double float64, g:Float float32,
+//	g:Date and g:Timestamp time.Time (UTC), g:UUID uuid.UUID, g:Class string,
+//	(g|gx):BigDecimal *big.Float, (g|gx):BigInteger *big.Int, gx:Byte int8, gx:Int16 int16, gx:Char string
+func RegisterType(graphsonType string, decoder TypeDecoder) {
+	scalarDecodersMu.Lock()
+	defer scalarDecodersMu.Unlock()
+	scalarDecoders[graphsonType] = decoder
+}
+
+func lookupScalarDecoder(graphsonType string) TypeDecoder {
+	scalarDecodersMu.RLock()
+	defer scalarDecodersMu.RUnlock()
+	return scalarDecoders[graphsonType]
+}
+
+// decodeTypedInterface decodes val (as already unmarshalled into an interface{}, e.g. by the graphson package),
+// so that any typed `{"@type":..., "@value":...}` value is decoded as by DecodeGraphSON
+func decodeTypedInterface(val interface{}) (interface{}, error) {
+	m, ok := val.(map[string]interface{})
+	if !ok {
+		return val, nil
+	}
+	if _, ok = m["@type"]; !ok {
+		return val, nil
+	}
+	raw, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeGraphSON(raw)
+}
+
+func decodeInt32(value json.RawMessage) (interface{}, error) {
+	var i int32
+	err := json.Unmarshal(value, &i)
+	return i, err
+}
+
+func decodeInt64(value json.RawMessage) (interface{}, error) {
+	var i int64
+	err := json.Unmarshal(value, &i)
+	return i, err
+}
+
+func decodeByte(value json.RawMessage) (interface{}, error) {
+	var i int8
+	err := json.Unmarshal(value, &i)
+	return i, err
+}
+
+func decodeInt16(value json.RawMessage) (interface{}, error) {
+	var i int16
+	err := json.Unmarshal(value, &i)
+	return i, err
+}
+
+// decodeFloatBits decodes a g:Double or g:Float, which may be the strings "NaN", "Infinity" or "-Infinity"
+func decodeFloatBits(value json.RawMessage, bitSize int) (float64, error) {
+	var f float64
+	if err := json.Unmarshal(value, &f); err == nil {
+		if bitSize == 32 && math.Abs(f) > math.MaxFloat32 {
+			return 0, errors.Errorf("value %v overflows float32", f)
+		}
+		return f, nil
+	}
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return 0, err
+	}
+	switch s {
+	case "NaN":
+		return math.NaN(), nil
+	case "Infinity":
+		return math.Inf(1), nil
+	case "-Infinity":
+		return math.Inf(-1), nil
+	}
+	return 0, errors.Errorf("invalid floating point value %q", s)
+}
+
+func decodeDouble(value json.RawMessage) (interface{}, error) {
+	return decodeFloatBits(value, 64)
+}
+
+func decodeFloat(value json.RawMessage) (interface{}, error) {
+	f, err := decodeFloatBits(value, 32)
+	return float32(f), err
+}
+
+// decodeDate decodes a g:Date or g:Timestamp (milliseconds since the epoch)
+func decodeDate(value json.RawMessage) (interface{}, error) {
+	var ms int64
+	if err := json.Unmarshal(value, &ms); err != nil {
+		return nil, err
+	}
+	return time.UnixMilli(ms).UTC(), nil
+}
+
+func decodeUUID(value json.RawMessage) (interface{}, error) {
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return nil, err
+	}
+	return uuid.FromString(s)
+}
+
+func decodeString(value json.RawMessage) (interface{}, error) {
+	var s string
+	err := json.Unmarshal(value, &s)
+	return s, err
+}
+
+// bigNumberString returns the number in value, which may be a JSON number or a string
+func bigNumberString(value json.RawMessage) (string, error) {
+	var n json.Number
+	if err := json.Unmarshal(value, &n); err != nil {
+		return "", err
+	}
+	return n.String(), nil
+}
+
+func decodeBigDecimal(value json.RawMessage) (interface{}, error) {
+	s, err := bigNumberString(value)
+	if err != nil {
+		return nil, err
+	}
+	// enough precision for every decimal digit (log2(10) < 4 bits per digit)
+	prec := uint(len(s)) * 4
+	if prec < 64 {
+		prec = 64
+	}
+	f, _, err := big.ParseFloat(s, 10, prec, big.ToNearestEven)
+	return f, err
+}
+
+func decodeBigInteger(value json.RawMessage) (interface{}, error) {
+	s, err := bigNumberString(value)
+	if err != nil {
+		return nil, err
+	}
+	i, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, errors.Errorf("invalid integer %q", s)
+	}
+	return i, nil
+}
@@ -0,0 +1,177 @@
+package gremgo
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+func TestDecodeScalars(t *testing.T) {
+	bigDec, _, _ := big.ParseFloat("12345678901234567890.123456789", 10, 30*4, big.ToNearestEven)
+	bigInt, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+
+	testData := []struct {
+		data     string
+		expected interface{}
+	}{
+		{`{"@type":"g:Int32","@value":-7}`, int32(-7)},
+		{`{"@type":"g:Int64","@value":9223372036854775807}`, int64(math.MaxInt64)},
+		{`{"@type":"g:Double","@value":1.25}`, 1.25},
+		{`{"@type":"g:Double","@value":"Infinity"}`, math.Inf(1)},
+		{`{"@type":"g:Double","@value":"-Infinity"}`, math.Inf(-1)},
+		{`{"@type":"g:Float","@value":0.5}`, float32(0.5)},
+		{`{"@type":"g:Date","@value":1481750076295}`, time.Date(2016, 12, 14, 21, 14, 36, 295000000, time.UTC)},
+		{`{"@type":"g:Timestamp","@value":1481750076295}`, time.Date(2016, 12, 14, 21, 14, 36, 295000000, time.UTC)},
+		{`{"@type":"g:UUID","@value":"41d2e28a-20a4-4ab0-b379-d810dede3786"}`, uuid.Must(uuid.FromString("41d2e28a-20a4-4ab0-b379-d810dede3786"))},
+		{`{"@type":"g:Class","@value":"java.io.File"}`, "java.io.File"},
+		{`{"@type":"gx:BigDecimal","@value":12345678901234567890.123456789}`, bigDec},
+		{`{"@type":"g:BigDecimal","@value":"12345678901234567890.123456789"}`, bigDec},
+		{`{"@type":"gx:BigInteger","@value":123456789012345678901234567890}`, bigInt},
+		{`{"@type":"gx:Byte","@value":1}`, int8(1)},
+		{`{"@type":"gx:Int16","@value":100}`, int16(100)},
+		{`{"@type":"gx:Char","@value":"x"}`, "x"},
+	}
+	for _, td := range testData {
+		val, err := DecodeGraphSON([]byte(td.data))
+		if err != nil {
+			t.Errorf("%s: %v", td.data, err)
+			continue
+		}
+		if expected, ok := td.expected.(*big.Float); ok {
+			if f, ok := val.(*big.Float); !ok || f.Cmp(expected) != 0 {
+				t.Errorf("%s: expected %v, got %#v", td.data, expected, val)
+			}
+		} else if !reflect.DeepEqual(val, td.expected) {
+			t.Errorf("%s: expected %#v, got %#v", td.data, td.expected, val)
+		}
+	}
+
+	val, err := DecodeGraphSON([]byte(`{"@type":"g:Double","@value":"NaN"}`))
+	if f, ok := val.(float64); err != nil || !ok || !math.IsNaN(f) {
+		t.Errorf("Expected NaN, got %#v %v", val, err)
+	}
+
+	for _, data := range []string{
+		`{"@type":"g:Int32","@value":2147483648}`,
+		`{"@type":"g:Double","@value":"lots"}`,
+		`{"@type":"g:Float","@value":1e300}`,
+		`{"@type":"g:Date","@value":"yesterday"}`,
+		`{"@type":"g:UUID","@value":"not-a-uuid"}`,
+		`{"@type":"gx:BigInteger","@value":"1.5"}`,
+		`{"@type":"gx:Byte","@value":128}`,
+	} {
+		if _, err := DecodeGraphSON([]byte(data)); err == nil {
+			t.Errorf("Expected error decoding %s", data)
+		}
+	}
+}
+
+type point struct{ X, Y float64 }
+
+func TestRegisterType(t *testing.T) {
+	const data = `{"@type":"g:List","@value":[{"@type":"test:Point","@value":{"x":{"@type":"g:Double","@value":1},"y":{"@type":"g:Double","@value":2}}}]}`
+
+	val, err := DecodeGraphSON([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := val.(List)[0].(TypedValue); !ok {
+		t.Fatalf("Expected unregistered type to decode as TypedValue, got %#v", val)
+	}
+
+	RegisterType("test:Point", func(value json.RawMessage) (interface{}, error) {
+		v, err := DecodeGraphSON(value)
+		if err != nil {
+			return nil, err
+		}
+		m := v.(Map)
+		x, _ := m.GetFloat64("x")
+		y, _ := m.GetFloat64("y")
+		return point{x, y}, nil
+	})
+	defer func() {
+		scalarDecodersMu.Lock()
+		delete(scalarDecoders, "test:Point")
+		scalarDecodersMu.Unlock()
+	}()
+
+	if val, err = DecodeGraphSON([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(val, List{point{1, 2}}) {
+		t.Errorf("Expected registered type to be decoded, got %#v", val)
+	}
+
+	// GetCtx leaves property values as unmarshalled by the graphson package, for DecodeVertices to decode
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c := newChunksClient()
+	go func() {
+		msg := <-c.requests
+		var req request
+		if err := json.Unmarshal(msg[len(mimeTypePrefix):], &req); err != nil {
+			t.Error(err)
+		}
+		vert := `{"@type":"g:List","@value":[{"@type":"g:Vertex","@value":{"id":"v1","label":"place","properties":{` +
+			`"at":[{"@type":"g:VertexProperty","@value":{"id":{"@type":"Type","@value":1},"value":{"@type":"test:Point","@value":` +
+			`{"x":{"@type":"g:Double","@value":1},"y":{"@type":"g:Double","@value":2}}},"label":"at"}}]}}}]}`
+		c.saveResponse(Response{RequestID: req.RequestID, Status: Status{Code: StatusSuccess}, Result: Result{Data: json.RawMessage(vert)}}, nil)
+	}()
+	verts, err := c.GetCtx(ctx, "g.V('v1')", nil, nil)
+	if err != nil || len(verts) != 1 {
+		t.Fatalf("Expected a vertex, got %+v %v", verts, err)
+	}
+	var places []struct {
+		At point `graph:"at,other"`
+	}
+	if err = DecodeVertices(verts, &places); err != nil {
+		t.Fatal(err)
+	}
+	if len(places) != 1 || places[0].At != (point{1, 2}) {
+		t.Errorf("Expected the registered type from GetCtx, got %+v", places)
+	}
+}
+
+func TestDecodeVertexScalars(t *testing.T) {
+	const data = `{"@type":"g:List","@value":[{"@type":"g:Vertex","@value":{"id":"v1","label":"event","properties":{` +
+		`"at":[{"@type":"g:VertexProperty","@value":{"id":{"@type":"Type","@value":1},"value":{"@type":"g:Date","@value":1481750076295},"label":"at"}}],` +
+		`"ref":[{"@type":"g:VertexProperty","@value":{"id":{"@type":"Type","@value":2},"value":{"@type":"g:UUID","@value":"41d2e28a-20a4-4ab0-b379-d810dede3786"},"label":"ref"}}],` +
+		`"total":[{"@type":"g:VertexProperty","@value":{"id":{"@type":"Type","@value":3},"value":{"@type":"gx:BigDecimal","@value":"42"},"label":"total"}}]` +
+		`}}}]}`
+	type event struct {
+		At    time.Time `graph:"at,other"`
+		Ref   uuid.UUID `graph:"ref,other"`
+		Total int       `graph:"total,number"`
+	}
+
+	var res []event
+	if err := DecodeVertices(dummyVertices(t, data), &res); err != nil {
+		t.Fatal(err)
+	}
+	expected := event{
+		At:    time.Date(2016, 12, 14, 21, 14, 36, 295000000, time.UTC),
+		Ref:   uuid.Must(uuid.FromString("41d2e28a-20a4-4ab0-b379-d810dede3786")),
+		Total: 42,
+	}
+	if len(res) != 1 || !reflect.DeepEqual(res[0], expected) {
+		t.Errorf("Expected %+v, got %+v", expected, res)
+	}
+
+	c := newClient()
+	props, err := c.deserializeResponseToProperties([]Response{{Result: Result{Data: json.RawMessage(
+		`{"@type":"g:List","@value":[{"@type":"g:VertexProperty","@value":{"id":{"@type":"Type","@value":1},"value":{"@type":"g:Int32","@value":3},"label":"n"}}]}`,
+	)}}})
+	if err != nil || !reflect.DeepEqual(props["n"], []interface{}{int32(3)}) {
+		t.Errorf("Expected decoded property value, got %#v %v", props, err)
+	}
+	count, err := c.deserializeResponseToCount([]Response{{Result: Result{Data: json.RawMessage(`{"@type":"g:List","@value":[{"@type":"g:Int32","@value":3}]}`)}}})
+	if err != nil || count != 3 {
+		t.Errorf("Expected count 3, got %d %v", count, err)
+	}
+}
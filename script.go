@@ -0,0 +1,195 @@
+package gremgo
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrorScriptFailed = errors.New("script statements failed")
+
+// ScriptMode sets whether a script runner continues after a statement fails
+type ScriptMode int
+
+const (
+	// ScriptStopOnError stops the script at the first statement which fails
+	ScriptStopOnError ScriptMode = iota
+	// ScriptContinueOnError runs every statement of the script, whether or not earlier statements failed
+	ScriptContinueOnError
+)
+
+// Statement is a statement of a script (see SplitStatements)
+type Statement struct {
+	Text string
+	Line int // the line of the script on which the statement starts (from 1)
+}
+
+// String returns the statement's line and text, e.g. "3: g.V().count()"
+func (s Statement) String() string {
+	return fmt.Sprintf("%d: %s", s.Line, s.Text)
+}
+
+// StatementResult is the outcome of running a statement of a script
+type StatementResult struct {
+	Statement
+	Responses []Response
+	Err       error
+	Duration  time.Duration
+}
+
+// SplitStatements splits script into its statements, which are separated by `;` or blank lines, other than in
+// strings, comments or brackets (e.g. a closure or a multi-line traversal). Statements which are only comments
+// are omitted.
+func SplitStatements(script string) (stmts []Statement) {
+	depth, line := 0, 1
+	codeStart := -1 // the offset of the first code of the current statement, rather than whitespace or comments
+	codeLine := 0
+	flush := func(end int) {
+		if codeStart >= 0 {
+			stmts = append(stmts, Statement{Text: strings.TrimSpace(script[codeStart:end]), Line: codeLine})
+		}
+		codeStart = -1
+	}
+	for i := 0; i < len(script); {
+		c := script[i]
+		switch {
+		case strings.HasPrefix(script[i:], "//"):
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				end = len(script) - i
+			}
+			i += end
+			continue
+		case strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				end = len(script) - i
+			} else {
+				end += 4
+			}
+			line += strings.Count(script[i:i+end], "\n")
+			i += end
+			continue
+		case c == ';' && depth == 0:
+			flush(i)
+			i++
+			continue
+		case c == '\n':
+			line++
+			if depth == 0 && isBlankLine(script[i+1:]) {
+				flush(i)
+			}
+			i++
+			continue
+		case isSpace(c):
+			i++
+			continue
+		}
+
+		if codeStart < 0 {
+			codeStart, codeLine = i, line
+		}
+		switch c {
+		case '\'', '"':
+			end := groovyStringEnd(script, i)
+			line += strings.Count(script[i:end], "\n")
+			i = end
+			continue
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			if depth > 0 {
+				depth--
+			}
+		}
+		i++
+	}
+	flush(len(script))
+	return
+}
+
+// isBlankLine returns whether s starts with a line which is only whitespace
+func isBlankLine(s string) bool {
+	end := strings.IndexByte(s, '\n')
+	return end >= 0 && strings.TrimSpace(s[:end]) == ""
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+// runScript runs the statements of script in order with exec, as per mode
+func runScript(ctx context.Context, script string, mode ScriptMode, exec func(ctx context.Context, query string) ([]Response, error)) (res []StatementResult, err error) {
+	failed := 0
+	for _, stmt := range SplitStatements(script) {
+		r := StatementResult{Statement: stmt}
+		start := time.Now()
+		if r.Err = ctx.Err(); r.Err == nil {
+			r.Responses, r.Err = exec(ctx, stmt.Text)
+		}
+		r.Duration = time.Since(start)
+		res = append(res, r)
+		if r.Err == nil {
+			continue
+		}
+		if mode == ScriptStopOnError {
+			return res, errors.Wrapf(r.Err, "statement %d (line %d)", len(res), stmt.Line)
+		}
+		failed++
+	}
+	if failed > 0 {
+		return res, errors.Wrapf(ErrorScriptFailed, "%d of %d", failed, len(res))
+	}
+	return
+}
+
+// ExecuteScriptCtx runs the statements of script (see SplitStatements) in order, each as a request with bindings,
+// returning the result of each statement run. With ScriptStopOnError, it stops at the first statement which fails,
+// returning its error; with ScriptContinueOnError, it runs every statement, failing with ErrorScriptFailed
+// if any failed.
+//
+// Each statement is a separate sessionless request, so a variable assigned in one statement is not defined in
+// the next: e.g. in `x = g.V(1).next(); g.V(x)`, the second statement fails. Pass such values as bindings, or run
+// the statements as a single request (e.g. with ExecuteCtx).
+func (c *Client) ExecuteScriptCtx(ctx context.Context, script string, bindings Bindings, mode ScriptMode) (res []StatementResult, err error) {
+	if c.conn.IsDisposed() {
+		return nil, ErrorConnectionDisposed
+	}
+	return runScript(ctx, script, mode, func(ctx context.Context, query string) ([]Response, error) {
+		return c.executeRequestCtx(ctx, query, bindings, nil)
+	})
+}
+
+// ExecuteScriptFileCtx runs the statements of the script file at path (see ExecuteScriptCtx)
+func (c *Client) ExecuteScriptFileCtx(ctx context.Context, path string, bindings Bindings, mode ScriptMode) (res []StatementResult, err error) {
+	var script []byte
+	if script, err = ioutil.ReadFile(path); err != nil {
+		return
+	}
+	return c.ExecuteScriptCtx(ctx, string(script), bindings, mode)
+}
+
+// ExecuteScriptCtx runs the statements of script in order on a connection of the pool (see Client.ExecuteScriptCtx)
+func (p *Pool) ExecuteScriptCtx(ctx context.Context, script string, bindings Bindings, mode ScriptMode) (res []StatementResult, err error) {
+	var pc *conn
+	if pc, err = p.connCtx(ctx); err != nil {
+		return nil, errors.Wrap(err, "ExecuteScriptCtx: Failed p.connCtx")
+	}
+	defer p.putConn(pc, err)
+	return pc.Client.ExecuteScriptCtx(ctx, script, bindings, mode)
+}
+
+// ExecuteScriptFileCtx runs the statements of the script file at path on a connection of the pool
+// (see Client.ExecuteScriptCtx)
+func (p *Pool) ExecuteScriptFileCtx(ctx context.Context, path string, bindings Bindings, mode ScriptMode) (res []StatementResult, err error) {
+	var pc *conn
+	if pc, err = p.connCtx(ctx); err != nil {
+		return nil, errors.Wrap(err, "ExecuteScriptFileCtx: Failed p.connCtx")
+	}
+	defer p.putConn(pc, err)
+	return pc.Client.ExecuteScriptFileCtx(ctx, path, bindings, mode)
+}
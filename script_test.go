@@ -0,0 +1,158 @@
+package gremgo
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const testScript = `// maintenance script
+g.V().hasLabel('old').drop();
+g.addV('a').property('note', 'semi; colon')
+
+/* multi-line
+   comment */
+g.V().hasLabel('a')
+  .property('note', "blank
+
+line in a string")
+
+g.V().sideEffect { it ->
+  it.get();
+
+  it.get()
+}.iterate() ; ;
+// only a comment
+
+`
+
+func TestSplitStatements(t *testing.T) {
+	expected := []Statement{
+		{"g.V().hasLabel('old').drop()", 2},
+		{"g.addV('a').property('note', 'semi; colon')", 3},
+		{"g.V().hasLabel('a')\n  .property('note', \"blank\n\nline in a string\")", 7},
+		{"g.V().sideEffect { it ->\n  it.get();\n\n  it.get()\n}.iterate()", 12},
+	}
+	if stmts := SplitStatements(testScript); !reflect.DeepEqual(stmts, expected) {
+		t.Errorf("Expected\n%q\ngot\n%q", expected, stmts)
+	}
+	if stmts := SplitStatements("  \n// nothing\n;"); len(stmts) != 0 {
+		t.Errorf("Expected no statements, got %q", stmts)
+	}
+}
+
+func TestRunScript(t *testing.T) {
+	ctx := context.Background()
+	var run []string
+	exec := func(ctx context.Context, query string) ([]Response, error) {
+		run = append(run, query)
+		if strings.Contains(query, "fail") {
+			return nil, errors.New("server error")
+		}
+		return []Response{{Status: Status{Code: StatusSuccess}}}, nil
+	}
+	script := "g.V().count()\n\ng.V('fail')\n\ng.E().count()"
+
+	res, err := runScript(ctx, script, ScriptStopOnError, exec)
+	if err == nil || !strings.Contains(err.Error(), "statement 2 (line 3)") {
+		t.Errorf("Expected the error of statement 2, got %v", err)
+	}
+	if len(res) != 2 || res[0].Err != nil || len(res[0].Responses) != 1 || res[1].Err == nil || len(run) != 2 {
+		t.Errorf("Expected to stop after the failed statement, got %+v", res)
+	}
+
+	run = nil
+	res, err = runScript(ctx, script, ScriptContinueOnError, exec)
+	if errors.Cause(err) != ErrorScriptFailed {
+		t.Errorf("Expected %v, got %v", ErrorScriptFailed, err)
+	}
+	if len(res) != 3 || res[1].Err == nil || res[2].Err != nil || res[2].Line != 5 || len(run) != 3 {
+		t.Errorf("Expected to run every statement, got %+v", res)
+	}
+	for _, r := range res {
+		if r.Duration <= 0 {
+			t.Errorf("Expected the duration of %s", r.Statement)
+		}
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	run = nil
+	if res, _ = runScript(cancelled, script, ScriptContinueOnError, exec); len(run) != 0 || res[0].Err != context.Canceled {
+		t.Errorf("Expected no statements run after cancellation, got %+v", res)
+	}
+}
+
+func TestExecuteScriptFileCtx(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	path := filepath.Join(t.TempDir(), "script.groovy")
+	if err := os.WriteFile(path, []byte("g.V(x).count();g.E(x).count()"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newChunksClient()
+	sent := make(chan string, 2)
+	go func() {
+		for i := 0; i < 2; i++ {
+			msg := <-c.requests
+			var req request
+			if err := json.Unmarshal(msg[len(mimeTypePrefix):], &req); err != nil {
+				t.Error(err)
+			}
+			sent <- req.Args["gremlin"].(string)
+			c.saveResponse(Response{RequestID: req.RequestID, Status: Status{Code: StatusNoContent}}, nil)
+		}
+	}()
+	SetBindingMode(BindingModeInterpolate)(c)
+	res, err := c.ExecuteScriptFileCtx(ctx, path, Bindings{"x": "v1"}, ScriptStopOnError)
+	if err != nil || len(res) != 2 {
+		t.Fatalf("Expected 2 results, got %+v %v", res, err)
+	}
+	if q := <-sent; q != "g.V('v1').count()" {
+		t.Errorf("Unexpected statement %s", q)
+	}
+	if q := <-sent; q != "g.E('v1').count()" {
+		t.Errorf("Unexpected statement %s", q)
+	}
+	assertNoRequestState(t, c)
+}
+
+// TestExecuteScriptCtxVariables shows that a variable assigned in one statement is not defined in the next,
+// as each statement is a separate sessionless request
+func TestExecuteScriptCtxVariables(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c := newChunksClient()
+	go func() {
+		for i := 0; i < 2; i++ {
+			msg := <-c.requests
+			var req request
+			if err := json.Unmarshal(msg[len(mimeTypePrefix):], &req); err != nil {
+				t.Error(err)
+			}
+			resp := Response{RequestID: req.RequestID, Status: Status{Code: StatusSuccess}, Result: Result{Data: json.RawMessage(`[]`)}}
+			if q := req.Args["gremlin"].(string); !strings.Contains(q, "x =") {
+				// as the server does, for a variable which is neither bound nor assigned in the request
+				resp = Response{RequestID: req.RequestID, Status: Status{Code: StatusScriptEvaluationError, Message: "No such property: x for class: Script1"}}
+			}
+			c.saveResponse(resp, resp.detectError())
+		}
+	}()
+	res, err := c.ExecuteScriptCtx(ctx, "x = g.V(1).next(); g.V(x).count()", nil, ScriptStopOnError)
+	if len(res) != 2 || res[0].Err != nil {
+		t.Fatalf("Expected the first statement to succeed, got %+v", res)
+	}
+	if err == nil || !strings.Contains(err.Error(), "statement 2") || !strings.Contains(err.Error(), "No such property: x") {
+		t.Errorf("Expected the error of the undefined variable in statement 2, got %v", err)
+	}
+	assertNoRequestState(t, c)
+}
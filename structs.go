@@ -0,0 +1,347 @@
+package gremgo
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/gremgo-neptune/traversal"
+	"github.com/pkg/errors"
+)
+
+// cardinalities are the tag options for the cardinality of a vertex property, e.g. `graph:"title,string,single"`
+var cardinalities = map[string]traversal.Token{
+	"single": traversal.CardinalitySingle,
+	"list":   traversal.CardinalityList,
+	"set":    traversal.CardinalitySet,
+}
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	rawMessageType      = reflect.TypeOf(json.RawMessage{})
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// structProperty is a property of a tagged struct, with its values (several, for the slice options e.g. []string)
+type structProperty struct {
+	name   string
+	values []interface{}
+	list   bool // from a slice option
+	key    bool // tag option "key": identifies the vertex in UpsertVertexCtx, in the absence of an id
+	// cardinality is the tag option "single", "list" or "set", if any, for the property() steps of the values
+	cardinality string
+}
+
+// cardinalityToken returns the token for the cardinality of prop, if it has one
+func (prop structProperty) cardinalityToken() (tok traversal.Token, ok bool) {
+	tok, ok = cardinalities[prop.cardinality]
+	return
+}
+
+// structElement is the id, label, outgoing and incoming vertex ids (for an edge) and properties of a tagged struct,
+// each of which may be unset
+type structElement struct {
+	id    interface{}
+	label string
+	from  interface{}
+	to    interface{}
+	props []structProperty
+}
+
+// readStruct reads the id, label, vertices and properties of data, a struct (or pointer to a struct) with `graph`
+// tags, returning ErrorNoGraphTags (with any id) if it has no tags.
+//
+// Data which is a GraphMarshaler is read as the result of MarshalGraph. The fields of embedded structs are read
+// as if they were fields of data, fields which are nil pointers are omitted, and a tag without a type option
+// (e.g. `graph:"title"` or `graph:"code,key"`) infers it from the field (see appendProperties).
+func readStruct(data interface{}) (sv structElement, err error) {
+	v, marshaled, err := marshalGraph(reflect.ValueOf(data))
+	if err != nil {
+		return
+	}
+	d := reflect.Indirect(v)
+	if marshaled && d.Kind() == reflect.Map {
+		if sv.props, err = appendMapProperties(nil, structProperty{}, d); err == nil && len(sv.props) == 0 {
+			err = ErrorNoGraphTags
+		}
+		return
+	}
+	if d.Kind() != reflect.Struct {
+		err = ErrorNotStruct
+		return
+	}
+	if idField, ok := d.Type().FieldByName("Id"); ok {
+		name, opts := parseTag(idField.Tag.Get("graph"))
+		if id, e := d.FieldByIndexErr(idField.Index); e == nil && len(name) == 0 && len(opts) == 0 {
+			if id = reflect.Indirect(id); id.IsValid() && !id.IsZero() {
+				sv.id = fmt.Sprint(id)
+			}
+		}
+	}
+
+	var tagged bool
+	if tagged, err = readFields(d, &sv); err != nil {
+		return
+	}
+	for _, prop := range sv.props {
+		if prop.list && prop.cardinality == "single" {
+			err = fmt.Errorf("interface field tag %q has a slice option, but single cardinality", prop.name)
+			return
+		}
+	}
+	if !tagged {
+		err = ErrorNoGraphTags
+	}
+	return
+}
+
+// readFields reads the tagged fields of the struct d (and of its embedded structs) into sv,
+// returning whether any were tagged
+func readFields(d reflect.Value, sv *structElement) (tagged bool, err error) {
+	for i := 0; i < d.NumField(); i++ {
+		field := d.Type().Field(i)
+		tag := field.Tag.Get("graph")
+		name, opts := parseTag(tag)
+		fv := d.Field(i)
+		if (len(name) == 0 || name == "-") && len(opts) == 0 {
+			if len(tag) == 0 && field.Anonymous && field.IsExported() {
+				if fv = reflect.Indirect(fv); fv.Kind() == reflect.Struct && fv.Type() != timeType {
+					var embedded bool
+					if embedded, err = readFields(fv, sv); err != nil {
+						return
+					}
+					tagged = tagged || embedded
+				}
+			}
+			continue
+		}
+		tagged = true
+		if field.PkgPath != "" {
+			err = fmt.Errorf("interface field tag %q is on unexported field: %q", name, field.Name)
+			return
+		}
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		}
+		val := fv.Interface()
+		prop := structProperty{name: name, key: opts.Contains("key")}
+		for _, option := range []string{"single", "list", "set"} {
+			if !opts.Contains(option) {
+				continue
+			}
+			if prop.cardinality != "" {
+				err = fmt.Errorf("interface field tag %q has options %s and %s, but only one cardinality", name, prop.cardinality, option)
+				return
+			}
+			prop.cardinality = option
+		}
+		if opts.Contains("id") {
+			if val != "" {
+				sv.id = fmt.Sprint(val)
+			}
+			continue
+		} else if opts.Contains("label") {
+			if fv.Kind() == reflect.String {
+				sv.label = fv.String()
+			}
+			continue
+		} else if opts.Contains("from") || opts.Contains("to") {
+			if val == "" {
+				continue
+			}
+			if opts.Contains("from") {
+				sv.from = fmt.Sprint(val)
+			} else {
+				sv.to = fmt.Sprint(val)
+			}
+			continue
+		} else if _, ok := graphMarshaler(fv); ok {
+			if sv.props, err = appendProperties(sv.props, prop, fv); err != nil {
+				return
+			}
+			continue
+		} else if opts.Contains("string") {
+			if fv.Kind() != reflect.String {
+				err = fmt.Errorf("interface field tag %q has option string, but field type: %T", name, val)
+				return
+			}
+			if str := fv.String(); str != "" {
+				prop.values = []interface{}{str}
+			}
+		} else if opts.Contains("bool") || opts.Contains("number") {
+			prop.values = []interface{}{val}
+		} else if opts.Contains("other") {
+			var v interface{}
+			if v, err = propertyValue(fv); err != nil {
+				err = errors.Wrapf(ErrorUnsupportedPropertyType, "property %q: %v", name, err)
+				return
+			}
+			prop.values = []interface{}{v}
+		} else if opts.Contains("[]string") {
+			if !isList(fv) || fv.Type().Elem().Kind() != reflect.String {
+				err = fmt.Errorf("interface field tag %q has option []string, but field type: %T", name, val)
+				return
+			}
+			prop.list = true
+			for i := 0; i < fv.Len(); i++ {
+				prop.values = append(prop.values, fv.Index(i).String())
+			}
+		} else if opts.Contains("[]bool") || opts.Contains("[]number") || opts.Contains("[]other") {
+			if !isList(fv) {
+				err = fmt.Errorf("interface field tag %q has a slice option, but field type: %T", name, val)
+				return
+			}
+			if prop.values, prop.list, err = propertyValues(fv); err != nil {
+				err = errors.Wrapf(ErrorUnsupportedPropertyType, "property %q: %v", name, err)
+				return
+			}
+		} else if hasOnlyModifiers(opts) {
+			if sv.props, err = appendProperties(sv.props, prop, fv); err != nil {
+				return
+			}
+			continue
+		} else {
+			err = fmt.Errorf("interface field tag needs recognised option, field: %q, tag: %q", field.Name, tag)
+			return
+		}
+		sv.props = append(sv.props, prop)
+	}
+	return
+}
+
+// hasOnlyModifiers returns true if opts has no type option, only (any of) the options key, single, list and set
+func hasOnlyModifiers(opts tagOptions) bool {
+	if len(opts) == 0 {
+		return true
+	}
+	for _, opt := range strings.Split(string(opts), ",") {
+		if _, ok := cardinalities[opt]; !ok && opt != "key" {
+			return false
+		}
+	}
+	return true
+}
+
+// appendProperties appends prop, with the values of v (see propertyValues, or of the result of MarshalGraph), to
+// props, omitting a nil pointer (or empty json.RawMessage) and flattening a map[string]T (see appendMapProperties)
+func appendProperties(props []structProperty, prop structProperty, v reflect.Value) ([]structProperty, error) {
+	v, _, err := marshalGraph(v)
+	if err != nil {
+		return nil, errors.Wrapf(err, "property %q", prop.name)
+	}
+	v, ok, err := indirect(v)
+	if err != nil {
+		return nil, errors.Wrapf(ErrorUnsupportedPropertyType, "property %q: %v", prop.name, err)
+	}
+	if !ok || (v.Type() == rawMessageType && v.Len() == 0) {
+		return props, nil
+	}
+	if v.Kind() == reflect.Map {
+		return appendMapProperties(props, prop, v)
+	}
+	if prop.values, prop.list, err = propertyValues(v); err != nil {
+		return nil, errors.Wrapf(ErrorUnsupportedPropertyType, "property %q: %v", prop.name, err)
+	}
+	return append(props, prop), nil
+}
+
+// appendMapProperties appends a property for each key of the map[string]T v to props (in key order),
+// named "<prop.name>.<key>" (or key, without prop.name)
+func appendMapProperties(props []structProperty, prop structProperty, v reflect.Value) ([]structProperty, error) {
+	if v.Type().Key().Kind() != reflect.String {
+		return nil, errors.Wrapf(ErrorUnsupportedPropertyType, "property %q: map key type %s", prop.name, v.Type().Key())
+	}
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	var err error
+	for _, key := range keys {
+		item := prop
+		item.name = key.String()
+		if prop.name != "" {
+			item.name = prop.name + "." + item.name
+		}
+		if props, err = appendProperties(props, item, v.MapIndex(key)); err != nil {
+			return nil, err
+		}
+	}
+	return props, nil
+}
+
+// propertyValues returns the values of v: a value for each item of a slice or array (omitting nil pointers),
+// else v itself (see propertyValue)
+func propertyValues(v reflect.Value) (vals []interface{}, list bool, err error) {
+	if !isList(v) || v.Type() == rawMessageType || v.Type().Implements(textMarshalerType) {
+		var val interface{}
+		if val, err = propertyValue(v); err != nil {
+			return nil, false, err
+		}
+		return []interface{}{val}, false, nil
+	}
+	for i := 0; i < v.Len(); i++ {
+		item, _, err := marshalGraph(v.Index(i))
+		if err != nil {
+			return nil, true, errors.Wrapf(err, "item %d", i)
+		}
+		item, ok, err := indirect(item)
+		if err != nil {
+			return nil, true, errors.Wrapf(err, "item %d", i)
+		}
+		if !ok {
+			continue
+		}
+		val, err := propertyValue(item)
+		if err != nil {
+			return nil, true, errors.Wrapf(err, "item %d", i)
+		}
+		vals = append(vals, val)
+	}
+	return vals, true, nil
+}
+
+// propertyValue returns v as a value for a property literal: a time.Time (as a datetime), the text of
+// a TextMarshaler (e.g. uuid.UUID), the JSON of a json.RawMessage (as a string), or a string, bool or number
+func propertyValue(v reflect.Value) (interface{}, error) {
+	switch {
+	case v.Type() == timeType:
+		return v.Interface(), nil
+	case v.Type() == rawMessageType:
+		return string(v.Bytes()), nil
+	case v.Type().Implements(textMarshalerType):
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+	switch v.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return v.Interface(), nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", v.Type())
+}
+
+// indirect returns the value of v through any pointers and interfaces, with ok false for a nil pointer
+// (which is omitted), and an error for nil
+func indirect(v reflect.Value) (_ reflect.Value, ok bool, err error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			if v.Kind() == reflect.Ptr {
+				return v, false, nil
+			}
+			break
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() || v.Kind() == reflect.Interface {
+		return v, false, errors.New("nil value")
+	}
+	return v, true, nil
+}
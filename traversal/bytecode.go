@@ -0,0 +1,177 @@
+package traversal
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// typedValue is a GraphSON typed value
+type typedValue struct {
+	Type  string      `json:"@type"`
+	Value interface{} `json:"@value"`
+}
+
+type bytecode struct {
+	Step [][]interface{} `json:"step"`
+}
+
+// Bytecode returns the traversal as GraphSON (v3) bytecode, e.g.
+// `{"@type":"g:Bytecode","@value":{"step":[["V"],["hasLabel","dataset"]]}}`
+func (t *Traversal) Bytecode() ([]byte, error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+	bc, err := bytecodeTraversal(t)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(bc)
+}
+
+func bytecodeTraversal(t *Traversal) (typedValue, error) {
+	steps := make([][]interface{}, len(t.steps))
+	for i, step := range t.steps {
+		instruction := make([]interface{}, 1, len(step.Args)+1)
+		instruction[0] = step.Name
+		for _, arg := range step.Args {
+			v, err := bytecodeValue(arg)
+			if err != nil {
+				return typedValue{}, errors.Wrapf(err, "%s()", step.Name)
+			}
+			instruction = append(instruction, v)
+		}
+		steps[i] = instruction
+	}
+	return typedValue{Type: "g:Bytecode", Value: bytecode{Step: steps}}, nil
+}
+
+// GraphSONValue returns val (any value which may be the argument of a step) as its GraphSON (v3) typed value,
+// ready to be marshalled as JSON
+func GraphSONValue(val interface{}) (interface{}, error) {
+	if err := checkArg(val); err != nil {
+		return nil, err
+	}
+	return bytecodeValue(val)
+}
+
+func bytecodeValue(arg interface{}) (interface{}, error) {
+	switch a := normalize(arg).(type) {
+	case nil, string, bool:
+		return a, nil
+	case int:
+		return bytecodeInt(int64(a)), nil
+	case int8:
+		return bytecodeInt(int64(a)), nil
+	case int16:
+		return bytecodeInt(int64(a)), nil
+	case int32:
+		return bytecodeInt(int64(a)), nil
+	case int64:
+		return typedValue{Type: "g:Int64", Value: a}, nil
+	case uint8:
+		return bytecodeInt(int64(a)), nil
+	case uint16:
+		return bytecodeInt(int64(a)), nil
+	case uint32:
+		return bytecodeInt(int64(a)), nil
+	case uint:
+		if err := checkUint(uint64(a)); err != nil {
+			return nil, err
+		}
+		return bytecodeInt(int64(a)), nil
+	case uint64:
+		if err := checkUint(a); err != nil {
+			return nil, err
+		}
+		return typedValue{Type: "g:Int64", Value: int64(a)}, nil
+	case float32:
+		return typedValue{Type: "g:Float", Value: bytecodeFloat(float64(a), 32)}, nil
+	case float64:
+		return typedValue{Type: "g:Double", Value: bytecodeFloat(a, 64)}, nil
+	case time.Time:
+		return typedValue{Type: "g:Date", Value: a.UnixMilli()}, nil
+	case Token:
+		return typedValue{Type: "g:" + a.enum, Value: a.name}, nil
+	case P:
+		return bytecodeP(a)
+	case list:
+		return bytecodeList(a)
+	case *Traversal:
+		if a == nil {
+			return nil, errors.Wrap(ErrorUnsupportedArgument, "nil *Traversal")
+		}
+		if a.source != anon {
+			return nil, ErrorSpawnedChildArgument
+		}
+		return bytecodeTraversal(a)
+	}
+	return nil, errors.Wrapf(ErrorUnsupportedArgument, "%T", arg)
+}
+
+// bytecodeInt returns i as a g:Int32 if it fits (e.g. for steps such as times(int)), else as a g:Int64
+func bytecodeInt(i int64) typedValue {
+	if i > math.MaxInt32 || i < math.MinInt32 {
+		return typedValue{Type: "g:Int64", Value: i}
+	}
+	return typedValue{Type: "g:Int32", Value: i}
+}
+
+// bytecodeFloat returns f as the shortest number which round-trips at bitSize, or as a string for NaN and infinities
+func bytecodeFloat(f float64, bitSize int) interface{} {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+	return json.Number(strconv.FormatFloat(f, 'g', -1, bitSize))
+}
+
+func bytecodeList(l list) (interface{}, error) {
+	vals := make([]interface{}, len(l))
+	for i, item := range l {
+		v, err := bytecodeValue(item)
+		if err != nil {
+			return nil, errors.Wrapf(err, "item %d", i)
+		}
+		vals[i] = v
+	}
+	return typedValue{Type: "g:List", Value: vals}, nil
+}
+
+// bytecodeP returns a predicate, whose value is a g:List for the predicates of several values (e.g. within,
+// whose values may also be given as a single list)
+func bytecodeP(p P) (interface{}, error) {
+	args := p.args
+	collection := p.operator == "within" || p.operator == "without"
+	if len(args) == 1 && collection {
+		if l, ok := normalize(args[0]).(list); ok {
+			args = l
+		}
+	}
+	vals := make([]interface{}, len(args))
+	for i, arg := range args {
+		v, err := bytecodeValue(arg)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s.%s()", p.class, p.operator)
+		}
+		vals[i] = v
+	}
+	var value interface{} = typedValue{Type: "g:List", Value: vals}
+	if len(vals) == 1 && !collection {
+		value = vals[0]
+	}
+	return typedValue{
+		Type: "g:" + p.class,
+		Value: struct {
+			Predicate string      `json:"predicate"`
+			Value     interface{} `json:"value"`
+		}{p.operator, value},
+	}, nil
+}
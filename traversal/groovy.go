@@ -0,0 +1,200 @@
+package traversal
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func writeGroovyTraversal(b *strings.Builder, t *Traversal) error {
+	b.WriteString(t.source)
+	if len(t.steps) == 0 && t.source == anon {
+		b.WriteString(".identity()")
+	}
+	for _, step := range t.steps {
+		b.WriteByte('.')
+		b.WriteString(step.Name)
+		b.WriteByte('(')
+		if err := writeGroovyArgs(b, step.Args); err != nil {
+			return errors.Wrapf(err, "%s()", step.Name)
+		}
+		b.WriteByte(')')
+	}
+	return nil
+}
+
+func writeGroovyArgs(b *strings.Builder, args []interface{}) error {
+	for i, arg := range args {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		if err := writeGroovyArg(b, arg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Literal returns val as a Gremlin-Groovy literal (e.g. `'it\'s'`, `1.5d`, `['a','b']` or `P.gt(30)`), for any
+// value which may be the argument of a step: strings, booleans, numbers, nil, time.Time, slices and arrays of these
+// (as lists), predicates, tokens and traversals
+func Literal(val interface{}) (string, error) {
+	if err := checkArg(val); err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := writeGroovyArg(&b, val); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func writeGroovyArg(b *strings.Builder, arg interface{}) error {
+	switch a := normalize(arg).(type) {
+	case *Traversal:
+		if a == nil {
+			return errors.Wrap(ErrorUnsupportedArgument, "nil *Traversal")
+		}
+		return writeGroovyTraversal(b, a)
+	case P:
+		b.WriteString(a.class)
+		b.WriteByte('.')
+		b.WriteString(a.operator)
+		b.WriteByte('(')
+		if err := writeGroovyArgs(b, a.args); err != nil {
+			return err
+		}
+		b.WriteByte(')')
+		return nil
+	case Token:
+		b.WriteString(a.groovy())
+		return nil
+	case list:
+		b.WriteByte('[')
+		if err := writeGroovyArgs(b, a); err != nil {
+			return err
+		}
+		b.WriteByte(']')
+		return nil
+	}
+	lit, err := groovyLiteral(arg)
+	if err != nil {
+		return err
+	}
+	b.WriteString(lit)
+	return nil
+}
+
+// groovyLiteral returns the Groovy literal for a scalar val
+func groovyLiteral(val interface{}) (string, error) {
+	switch v := normalize(val).(type) {
+	case nil:
+		return "null", nil
+	case string:
+		if err := checkString(v); err != nil {
+			return "", err
+		}
+		return quoteGroovy(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return groovyInt(int64(v)), nil
+	case int8:
+		return groovyInt(int64(v)), nil
+	case int16:
+		return groovyInt(int64(v)), nil
+	case int32:
+		return groovyInt(int64(v)), nil
+	case int64:
+		return groovyInt(v), nil
+	case uint8:
+		return groovyInt(int64(v)), nil
+	case uint16:
+		return groovyInt(int64(v)), nil
+	case uint32:
+		return groovyInt(int64(v)), nil
+	case uint:
+		if err := checkUint(uint64(v)); err != nil {
+			return "", err
+		}
+		return groovyInt(int64(v)), nil
+	case uint64:
+		if err := checkUint(v); err != nil {
+			return "", err
+		}
+		return groovyInt(int64(v)), nil
+	case float32:
+		return groovyFloat(float64(v), 32), nil
+	case float64:
+		return groovyFloat(v, 64), nil
+	case time.Time:
+		return "datetime(" + quoteGroovy(v.UTC().Format(time.RFC3339Nano)) + ")", nil
+	}
+	return "", errors.Wrapf(ErrorUnsupportedArgument, "%T", val)
+}
+
+// groovyInt returns an integer literal, suffixed as a long if it does not fit an int
+func groovyInt(i int64) string {
+	s := strconv.FormatInt(i, 10)
+	if i > math.MaxInt32 || i < math.MinInt32 {
+		s += "L"
+	}
+	return s
+}
+
+// groovyFloat returns a double (or, for bitSize 32, float) literal, since an unsuffixed decimal is a BigDecimal
+func groovyFloat(f float64, bitSize int) string {
+	class, suffix := "Double", "d"
+	if bitSize == 32 {
+		class, suffix = "Float", "f"
+	}
+	switch {
+	case math.IsNaN(f):
+		return class + ".NaN"
+	case math.IsInf(f, 1):
+		return class + ".POSITIVE_INFINITY"
+	case math.IsInf(f, -1):
+		return class + ".NEGATIVE_INFINITY"
+	}
+	return strconv.FormatFloat(f, 'g', -1, bitSize) + suffix
+}
+
+// quoteGroovy returns s as a single-quoted Groovy string (which is not interpolated), escaping backslashes,
+// quotes and control characters
+func quoteGroovy(s string) string {
+	var b strings.Builder
+	b.Grow(len(s) + 2)
+	b.WriteByte('\'')
+	for _, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '\'':
+			b.WriteString(`\'`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		default:
+			if r < 0x20 || r == 0x7f || r == 0x2028 || r == 0x2029 {
+				b.WriteString(`\u`)
+				h := strconv.FormatInt(int64(r), 16)
+				b.WriteString(strings.Repeat("0", 4-len(h)))
+				b.WriteString(h)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
@@ -0,0 +1,110 @@
+package traversal
+
+import (
+	"math"
+	"reflect"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// list is a list value (from any slice or array), rendered as a Groovy list `[...]` or as a g:List
+type list []interface{}
+
+// normalize returns val as one of the types handled when rendering: values of named scalar types
+// (e.g. `type State string`) as their underlying type, and slices and arrays as a list
+func normalize(val interface{}) interface{} {
+	switch val.(type) {
+	case nil, string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64,
+		time.Time, Token, P, *Traversal, list:
+		return val
+	}
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String()
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.Int:
+		return int(rv.Int())
+	case reflect.Int8:
+		return int8(rv.Int())
+	case reflect.Int16:
+		return int16(rv.Int())
+	case reflect.Int32:
+		return int32(rv.Int())
+	case reflect.Int64:
+		return rv.Int()
+	case reflect.Uint:
+		return uint(rv.Uint())
+	case reflect.Uint8:
+		return uint8(rv.Uint())
+	case reflect.Uint16:
+		return uint16(rv.Uint())
+	case reflect.Uint32:
+		return uint32(rv.Uint())
+	case reflect.Uint64:
+		return rv.Uint()
+	case reflect.Float32:
+		return float32(rv.Float())
+	case reflect.Float64:
+		return rv.Float()
+	case reflect.Slice, reflect.Array:
+		l := make(list, rv.Len())
+		for i := range l {
+			l[i] = rv.Index(i).Interface()
+		}
+		return l
+	}
+	return val
+}
+
+// checkArg returns an error if arg cannot be rendered
+func checkArg(arg interface{}) error {
+	switch a := normalize(arg).(type) {
+	case nil, bool, int, int8, int16, int32, int64, uint8, uint16, uint32, float32, float64, time.Time, Token:
+		return nil
+	case string:
+		return checkString(a)
+	case uint:
+		return checkUint(uint64(a))
+	case uint64:
+		return checkUint(a)
+	case *Traversal:
+		if a == nil {
+			return errors.Wrap(ErrorUnsupportedArgument, "nil *Traversal")
+		}
+		return a.err
+	case P:
+		for _, pa := range a.args {
+			if err := checkArg(pa); err != nil {
+				return errors.Wrapf(err, "%s.%s()", a.class, a.operator)
+			}
+		}
+		return nil
+	case list:
+		for i, item := range a {
+			if err := checkArg(item); err != nil {
+				return errors.Wrapf(err, "item %d", i)
+			}
+		}
+		return nil
+	}
+	return errors.Wrapf(ErrorUnsupportedArgument, "%T", arg)
+}
+
+// checkString returns an error if s is not valid UTF-8, which cannot be represented in a script
+func checkString(s string) error {
+	if !utf8.ValidString(s) {
+		return errors.Wrapf(ErrorUnsupportedArgument, "invalid UTF-8 string %q", s)
+	}
+	return nil
+}
+
+func checkUint(u uint64) error {
+	if u > math.MaxInt64 {
+		return errors.Wrapf(ErrorUnsupportedArgument, "%d overflows int64", u)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package traversal
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+func TestLiteral(t *testing.T) {
+	type count uint16
+	testData := []struct {
+		val      interface{}
+		expected string
+	}{
+		{"plain", `'plain'`},
+		{`it's "quoted" \ here`, `'it\'s "quoted" \\ here'`},
+		{"$x ${x.drop()}", `'$x ${x.drop()}'`},
+		{"lines\r\n\tand\b\f\x00\x1f\x7f\u2028\u2029", `'lines\r\n\tand\b\f\u0000\u001f\u007f\u2028\u2029'`},
+		{"unicode: é 日本 🎉", `'unicode: é 日本 🎉'`},
+		{true, "true"},
+		{nil, "null"},
+		{int32(math.MinInt32), "-2147483648"},
+		{int64(math.MinInt32) - 1, "-2147483649L"},
+		{uint64(math.MaxInt64), "9223372036854775807L"},
+		{count(7), "7"},
+		{1e21, "1e+21d"},
+		{float32(math.Inf(-1)), "Float.NEGATIVE_INFINITY"},
+		{[]string{"a", "b"}, "['a','b']"},
+		{[][]int{{1}, {}}, "[[1],[]]"},
+		{[]interface{}{"a", 1, nil, Gt(2)}, "['a',1,null,P.gt(2)]"},
+		{time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC), "datetime('2020-02-29T00:00:00Z')"},
+		{TLabel, "T.label"},
+		{Anon().Out("x"), "__.out('x')"},
+	}
+	for _, td := range testData {
+		got, err := Literal(td.val)
+		if err != nil {
+			t.Errorf("%#v: %v", td.val, err)
+			continue
+		}
+		if got != td.expected {
+			t.Errorf("%#v: expected %s, got %s", td.val, td.expected, got)
+		}
+	}
+
+	for _, val := range []interface{}{"\xc3\x28", uint(math.MaxUint64), map[string]string{}, struct{}{}, []func(){nil}} {
+		if _, err := Literal(val); errors.Cause(err) != ErrorUnsupportedArgument {
+			t.Errorf("%#v: expected %v, got %v", val, ErrorUnsupportedArgument, err)
+		}
+	}
+}
+
+// parseGroovyString parses a single-quoted Groovy string literal, as the Groovy lexer would,
+// returning the string and the remainder of the input after the closing quote
+func parseGroovyString(lit string) (s, rest string, err error) {
+	if !strings.HasPrefix(lit, "'") || strings.HasPrefix(lit, "'''") {
+		return "", "", errors.New("not a single-quoted string")
+	}
+	var b strings.Builder
+	for i := 1; i < len(lit); {
+		r, size := utf8.DecodeRuneInString(lit[i:])
+		switch {
+		case r == '\'':
+			return b.String(), lit[i+1:], nil
+		case r == '\n' || r == '\r':
+			return "", "", errors.New("line break in single-quoted string")
+		case r != '\\':
+			b.WriteRune(r)
+			i += size
+			continue
+		}
+		if i+1 >= len(lit) {
+			return "", "", errors.New("unterminated escape")
+		}
+		switch esc := lit[i+1]; esc {
+		case '\\', '\'', '"', '$':
+			b.WriteByte(esc)
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+6 > len(lit) {
+				return "", "", errors.New("short unicode escape")
+			}
+			code, err := strconv.ParseUint(lit[i+2:i+6], 16, 16)
+			if err != nil {
+				return "", "", err
+			}
+			b.WriteRune(rune(code))
+			i += 4
+		default:
+			return "", "", errors.Errorf("invalid escape \\%c", esc)
+		}
+		i += 2
+	}
+	return "", "", errors.New("unterminated string")
+}
+
+func FuzzLiteralString(f *testing.F) {
+	for _, seed := range []string{"", "plain", `it's`, `\'`, "$x ${y}", "a\nb\r\n", "\x00\x7f ", "日本 🎉", "\xff"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		lit, err := Literal(s)
+		if !utf8.ValidString(s) {
+			if errors.Cause(err) != ErrorUnsupportedArgument {
+				t.Fatalf("%q: expected %v, got %v", s, ErrorUnsupportedArgument, err)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("%q: %v", s, err)
+		}
+		for _, r := range lit {
+			if r < 0x20 || r == 0x7f || r == 0x2028 || r == 0x2029 {
+				t.Fatalf("%q: literal %q contains unescaped control character %U", s, lit, r)
+			}
+		}
+		parsed, rest, err := parseGroovyString(lit)
+		if err != nil {
+			t.Fatalf("%q: parsing %s: %v", s, lit, err)
+		}
+		if rest != "" {
+			t.Fatalf("%q: literal %s ends early, before %q", s, lit, rest)
+		}
+		if parsed != s {
+			t.Fatalf("%q: round-tripped as %q", s, parsed)
+		}
+	})
+}
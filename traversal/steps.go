@@ -0,0 +1,269 @@
+package traversal
+
+// The step methods below cover the steps supported by Amazon Neptune (which excludes lambdas, and steps such as
+// io(), program() and subgraph()); any other step may be added with Step.
+
+// V adds a step to the vertices (with ids, if given), e.g. mid-traversal
+func (t *Traversal) V(ids ...interface{}) *Traversal { return t.add("V", ids...) }
+
+// E adds a step to the edges (with ids, if given)
+func (t *Traversal) E(ids ...interface{}) *Traversal { return t.add("E", ids...) }
+
+// AddV adds a vertex with label
+func (t *Traversal) AddV(label string) *Traversal { return t.add("addV", label) }
+
+// AddE adds an edge with label (see From and To)
+func (t *Traversal) AddE(label string) *Traversal { return t.add("addE", label) }
+
+// From sets the outgoing vertex of AddE, as a step label or a traversal
+func (t *Traversal) From(vertex interface{}) *Traversal { return t.add("from", vertex) }
+
+// To sets the incoming vertex of AddE, as a step label or a traversal
+func (t *Traversal) To(vertex interface{}) *Traversal { return t.add("to", vertex) }
+
+// Aggregate adds the traversers to the side effect key (eagerly)
+func (t *Traversal) Aggregate(key string) *Traversal { return t.add("aggregate", key) }
+
+// And filters for traversers for which every traversal has a result
+func (t *Traversal) And(ts ...*Traversal) *Traversal { return t.add("and", traversalArgs(ts)...) }
+
+// As labels the step, for later Select or Where
+func (t *Traversal) As(labels ...string) *Traversal { return t.add("as", toArgs(labels)...) }
+
+// Barrier collects all traversers before continuing
+func (t *Traversal) Barrier() *Traversal { return t.add("barrier") }
+
+// Both moves to the adjacent vertices, over edges with labels (if given)
+func (t *Traversal) Both(labels ...string) *Traversal { return t.add("both", toArgs(labels)...) }
+
+// BothE moves to the incident edges with labels (if given)
+func (t *Traversal) BothE(labels ...string) *Traversal { return t.add("bothE", toArgs(labels)...) }
+
+// BothV moves to both vertices of an edge
+func (t *Traversal) BothV() *Traversal { return t.add("bothV") }
+
+// By modulates the previous step (e.g. Order, Group, Project), with a key, traversal, token and/or order
+func (t *Traversal) By(args ...interface{}) *Traversal { return t.add("by", args...) }
+
+// Cap emits the side effects with keys
+func (t *Traversal) Cap(keys ...string) *Traversal { return t.add("cap", toArgs(keys)...) }
+
+// Choose branches on a predicate or traversal (see Option)
+func (t *Traversal) Choose(args ...interface{}) *Traversal { return t.add("choose", args...) }
+
+// Coalesce emits the results of the first traversal which has any
+func (t *Traversal) Coalesce(ts ...*Traversal) *Traversal {
+	return t.add("coalesce", traversalArgs(ts)...)
+}
+
+// Coin filters traversers randomly, with probability
+func (t *Traversal) Coin(probability float64) *Traversal { return t.add("coin", probability) }
+
+// Constant replaces each traverser with val
+func (t *Traversal) Constant(val interface{}) *Traversal { return t.add("constant", val) }
+
+// Count counts the traversers (or, with Local, the items of each)
+func (t *Traversal) Count(scope ...Token) *Traversal { return t.add("count", tokenArgs(scope)...) }
+
+// CyclicPath filters for traversers whose path has repeated objects
+func (t *Traversal) CyclicPath() *Traversal { return t.add("cyclicPath") }
+
+// Dedup removes repeated traversers (or, given labels, repeated combinations of the labelled objects)
+func (t *Traversal) Dedup(args ...interface{}) *Traversal { return t.add("dedup", args...) }
+
+// Drop removes the elements or properties
+func (t *Traversal) Drop() *Traversal { return t.add("drop") }
+
+// ElementMap maps each element to its id, label and properties with keys (or all properties)
+func (t *Traversal) ElementMap(keys ...string) *Traversal {
+	return t.add("elementMap", toArgs(keys)...)
+}
+
+// Emit emits the traversers of Repeat (for which the traversal or predicate, if given, holds)
+func (t *Traversal) Emit(args ...interface{}) *Traversal { return t.add("emit", args...) }
+
+// Fold gathers the traversers into a single list
+func (t *Traversal) Fold() *Traversal { return t.add("fold") }
+
+// Group groups the traversers into a map (see By), or into the side effect key
+func (t *Traversal) Group(key ...string) *Traversal { return t.add("group", toArgs(key)...) }
+
+// GroupCount counts the traversers in each group (see By), or into the side effect key
+func (t *Traversal) GroupCount(key ...string) *Traversal { return t.add("groupCount", toArgs(key)...) }
+
+// Has filters on a property, e.g. Has("name"), Has("name", "cpih"), Has("age", Gt(30)) or Has("person", "name", "x")
+func (t *Traversal) Has(args ...interface{}) *Traversal { return t.add("has", args...) }
+
+// HasID filters for elements with any of ids (or a predicate)
+func (t *Traversal) HasID(ids ...interface{}) *Traversal { return t.add("hasId", ids...) }
+
+// HasKey filters for properties with any of keys
+func (t *Traversal) HasKey(keys ...string) *Traversal { return t.add("hasKey", toArgs(keys)...) }
+
+// HasLabel filters for elements with any of labels
+func (t *Traversal) HasLabel(labels ...string) *Traversal {
+	return t.add("hasLabel", toArgs(labels)...)
+}
+
+// HasNot filters for elements without the property key
+func (t *Traversal) HasNot(key string) *Traversal { return t.add("hasNot", key) }
+
+// HasValue filters for properties with any of vals (or a predicate)
+func (t *Traversal) HasValue(vals ...interface{}) *Traversal { return t.add("hasValue", vals...) }
+
+// ID maps each element to its id
+func (t *Traversal) ID() *Traversal { return t.add("id") }
+
+// Identity passes the traversers through unchanged
+func (t *Traversal) Identity() *Traversal { return t.add("identity") }
+
+// In moves to the adjacent vertices over incoming edges, with labels (if given)
+func (t *Traversal) In(labels ...string) *Traversal { return t.add("in", toArgs(labels)...) }
+
+// InE moves to the incoming edges, with labels (if given)
+func (t *Traversal) InE(labels ...string) *Traversal { return t.add("inE", toArgs(labels)...) }
+
+// InV moves to the incoming vertex of an edge
+func (t *Traversal) InV() *Traversal { return t.add("inV") }
+
+// Inject adds vals to the traversers
+func (t *Traversal) Inject(vals ...interface{}) *Traversal { return t.add("inject", vals...) }
+
+// Is filters for traversers equal to val (or matching a predicate)
+func (t *Traversal) Is(val interface{}) *Traversal { return t.add("is", val) }
+
+// Key maps each property to its key
+func (t *Traversal) Key() *Traversal { return t.add("key") }
+
+// Label maps each element to its label
+func (t *Traversal) Label() *Traversal { return t.add("label") }
+
+// Limit passes at most n traversers
+func (t *Traversal) Limit(n int64) *Traversal { return t.add("limit", n) }
+
+// Local runs the traversal on each traverser separately
+func (t *Traversal) Local(lt *Traversal) *Traversal { return t.add("local", lt) }
+
+// Loops maps each traverser to the number of times it has been through Repeat
+func (t *Traversal) Loops() *Traversal { return t.add("loops") }
+
+// Match filters for traversers matching all of the (As-labelled) traversals
+func (t *Traversal) Match(ts ...*Traversal) *Traversal { return t.add("match", traversalArgs(ts)...) }
+
+// Max maps the traversers (or, with Local, the items of each) to their greatest
+func (t *Traversal) Max(scope ...Token) *Traversal { return t.add("max", tokenArgs(scope)...) }
+
+// Mean maps the traversers (or, with Local, the items of each) to their mean
+func (t *Traversal) Mean(scope ...Token) *Traversal { return t.add("mean", tokenArgs(scope)...) }
+
+// Min maps the traversers (or, with Local, the items of each) to their least
+func (t *Traversal) Min(scope ...Token) *Traversal { return t.add("min", tokenArgs(scope)...) }
+
+// Not filters for traversers for which nt has no result
+func (t *Traversal) Not(nt *Traversal) *Traversal { return t.add("not", nt) }
+
+// Option adds a branch to Choose, for a key (or predicate) and its traversal
+func (t *Traversal) Option(args ...interface{}) *Traversal { return t.add("option", args...) }
+
+// Optional emits the results of ot, or else the traverser
+func (t *Traversal) Optional(ot *Traversal) *Traversal { return t.add("optional", ot) }
+
+// Or filters for traversers for which any traversal has a result
+func (t *Traversal) Or(ts ...*Traversal) *Traversal { return t.add("or", traversalArgs(ts)...) }
+
+// Order sorts the traversers (or, with Local, the items of each), by the following By steps
+func (t *Traversal) Order(scope ...Token) *Traversal { return t.add("order", tokenArgs(scope)...) }
+
+// OtherV moves to the vertex of an edge which was not traversed from
+func (t *Traversal) OtherV() *Traversal { return t.add("otherV") }
+
+// Out moves to the adjacent vertices over outgoing edges, with labels (if given)
+func (t *Traversal) Out(labels ...string) *Traversal { return t.add("out", toArgs(labels)...) }
+
+// OutE moves to the outgoing edges, with labels (if given)
+func (t *Traversal) OutE(labels ...string) *Traversal { return t.add("outE", toArgs(labels)...) }
+
+// OutV moves to the outgoing vertex of an edge
+func (t *Traversal) OutV() *Traversal { return t.add("outV") }
+
+// Path maps each traverser to its path
+func (t *Traversal) Path() *Traversal { return t.add("path") }
+
+// Project maps each traverser to a map with keys, whose values are given by the following By steps
+func (t *Traversal) Project(keys ...string) *Traversal { return t.add("project", toArgs(keys)...) }
+
+// Properties moves to the properties with keys (or all properties)
+func (t *Traversal) Properties(keys ...string) *Traversal {
+	return t.add("properties", toArgs(keys)...)
+}
+
+// Property sets a property, e.g. Property("name", "cpih") or Property(CardinalitySingle, "name", "cpih")
+func (t *Traversal) Property(args ...interface{}) *Traversal { return t.add("property", args...) }
+
+// Range passes the traversers from low (inclusive) to high (exclusive)
+func (t *Traversal) Range(low, high int64) *Traversal { return t.add("range", low, high) }
+
+// Repeat loops over rt (see Times, Until and Emit)
+func (t *Traversal) Repeat(rt *Traversal) *Traversal { return t.add("repeat", rt) }
+
+// Sample passes n traversers at random
+func (t *Traversal) Sample(n int) *Traversal { return t.add("sample", n) }
+
+// Select maps each traverser to the objects with (As or map) keys, or to the keys or values of a map
+func (t *Traversal) Select(args ...interface{}) *Traversal { return t.add("select", args...) }
+
+// SideEffect runs st for each traverser, passing the traverser through unchanged
+func (t *Traversal) SideEffect(st *Traversal) *Traversal { return t.add("sideEffect", st) }
+
+// SimplePath filters for traversers whose path has no repeated objects
+func (t *Traversal) SimplePath() *Traversal { return t.add("simplePath") }
+
+// Skip passes all but the first n traversers
+func (t *Traversal) Skip(n int64) *Traversal { return t.add("skip", n) }
+
+// Store adds the traversers to the side effect key (lazily)
+func (t *Traversal) Store(key string) *Traversal { return t.add("store", key) }
+
+// Sum maps the traversers (or, with Local, the items of each) to their sum
+func (t *Traversal) Sum(scope ...Token) *Traversal { return t.add("sum", tokenArgs(scope)...) }
+
+// Tail passes the last n traversers (or the last one)
+func (t *Traversal) Tail(n ...int64) *Traversal {
+	args := make([]interface{}, len(n))
+	for i := range n {
+		args[i] = n[i]
+	}
+	return t.add("tail", args...)
+}
+
+// TimeLimit passes traversers for at most ms milliseconds
+func (t *Traversal) TimeLimit(ms int64) *Traversal { return t.add("timeLimit", ms) }
+
+// Times sets the number of loops of Repeat
+func (t *Traversal) Times(n int) *Traversal { return t.add("times", n) }
+
+// Unfold flattens lists and maps into their items
+func (t *Traversal) Unfold() *Traversal { return t.add("unfold") }
+
+// Union emits the results of every traversal
+func (t *Traversal) Union(ts ...*Traversal) *Traversal { return t.add("union", traversalArgs(ts)...) }
+
+// Until ends Repeat for traversers for which the traversal or predicate holds
+func (t *Traversal) Until(cond interface{}) *Traversal { return t.add("until", cond) }
+
+// Value maps each property to its value
+func (t *Traversal) Value() *Traversal { return t.add("value") }
+
+// ValueMap maps each element to its properties with keys (or all properties), and, given true, its id and label
+func (t *Traversal) ValueMap(args ...interface{}) *Traversal { return t.add("valueMap", args...) }
+
+// Values maps each element to the values of its properties with keys (or all properties)
+func (t *Traversal) Values(keys ...string) *Traversal { return t.add("values", toArgs(keys)...) }
+
+// Where filters on a predicate (of As-labelled objects) or a traversal
+func (t *Traversal) Where(args ...interface{}) *Traversal { return t.add("where", args...) }
+
+// With configures the previous step, with key (and val)
+func (t *Traversal) With(key string, val ...interface{}) *Traversal {
+	return t.add("with", append([]interface{}{key}, val...)...)
+}
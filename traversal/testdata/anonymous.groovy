@@ -0,0 +1 @@
+g.V().repeat(__.out('next').simplePath()).until(__.hasLabel('end')).times(5).emit().union(__.values('a'),__.identity())
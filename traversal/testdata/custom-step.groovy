@@ -0,0 +1 @@
+g.V().valueMap(true).with('~tinkerpop.valueMap.tokens')
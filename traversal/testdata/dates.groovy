@@ -0,0 +1 @@
+g.V().has('at',P.gte(datetime('2021-01-02T02:04:05.006Z')))
@@ -0,0 +1 @@
+g.addE('hasEdition').from(__.V('a')).to(__.V('b')).property('since',1600000000000L)
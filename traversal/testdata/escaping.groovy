@@ -0,0 +1 @@
+g.V().has('name','it\'s a \\ "test"\n$x ${y}\u0000\u2028')
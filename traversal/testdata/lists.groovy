@@ -0,0 +1 @@
+g.inject(['a','b\''],[1,2],[],'published').has('state',P.within(['draft','published']))
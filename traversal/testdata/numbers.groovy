@@ -0,0 +1 @@
+g.inject(1,2,9223372036854775807L,-3,4,1.5d,0.1f,Double.POSITIVE_INFINITY,Double.NaN,true,null)
@@ -0,0 +1 @@
+g.V().has('age',P.between(18,65)).has('name',P.within('a','b')).has('code',TextP.startingWith('cpi')).where(P.neq('x'))
@@ -0,0 +1 @@
+g.V().hasLabel('dataset').project('id','editions').by(T.id).by(__.out('hasEdition').count()).range(0,20)
@@ -0,0 +1 @@
+g.V().hasLabel('x').has('k','v').out('e').limit(10)
@@ -0,0 +1 @@
+g.V().order().by('name',Order.desc).by(T.id,Order.asc).select(Column.values).unfold().count(Scope.local)
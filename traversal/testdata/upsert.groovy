@@ -0,0 +1 @@
+g.V('id1').fold().coalesce(__.unfold(),__.addV('dataset').property(T.id,'id1')).property(VertexProperty.Cardinality.single,'title','CPIH')
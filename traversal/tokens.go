@@ -0,0 +1,120 @@
+package traversal
+
+// Token is an enumerated Gremlin value, e.g. T.id or Order.desc
+type Token struct {
+	enum string
+	name string
+}
+
+var (
+	TId    = Token{"T", "id"}
+	TLabel = Token{"T", "label"}
+	TKey   = Token{"T", "key"}
+	TValue = Token{"T", "value"}
+
+	Asc     = Token{"Order", "asc"}
+	Desc    = Token{"Order", "desc"}
+	Shuffle = Token{"Order", "shuffle"}
+
+	ColumnKeys   = Token{"Column", "keys"}
+	ColumnValues = Token{"Column", "values"}
+
+	Local  = Token{"Scope", "local"}
+	Global = Token{"Scope", "global"}
+
+	CardinalitySingle = Token{"Cardinality", "single"}
+	CardinalityList   = Token{"Cardinality", "list"}
+	CardinalitySet    = Token{"Cardinality", "set"}
+
+	PopFirst = Token{"Pop", "first"}
+	PopLast  = Token{"Pop", "last"}
+	PopAll   = Token{"Pop", "all"}
+	PopMixed = Token{"Pop", "mixed"}
+)
+
+// groovyEnums are the Groovy class names of the enums whose names differ from their GraphSON types
+var groovyEnums = map[string]string{
+	"Cardinality": "VertexProperty.Cardinality",
+}
+
+func (tok Token) groovy() string {
+	enum := tok.enum
+	if g, ok := groovyEnums[enum]; ok {
+		enum = g
+	}
+	return enum + "." + tok.name
+}
+
+func tokenArgs(toks []Token) []interface{} {
+	args := make([]interface{}, len(toks))
+	for i, tok := range toks {
+		args[i] = tok
+	}
+	return args
+}
+
+// P is a predicate, e.g. Gt(30) or StartingWith("cpih"), for steps such as Has, Is and Where
+type P struct {
+	class    string
+	operator string
+	args     []interface{}
+}
+
+func newP(operator string, args ...interface{}) P {
+	return P{class: "P", operator: operator, args: args}
+}
+
+func newTextP(operator string, s string) P {
+	return P{class: "TextP", operator: operator, args: []interface{}{s}}
+}
+
+// Eq is the predicate `P.eq(val)`
+func Eq(val interface{}) P { return newP("eq", val) }
+
+// Neq is the predicate `P.neq(val)`
+func Neq(val interface{}) P { return newP("neq", val) }
+
+// Lt is the predicate `P.lt(val)`
+func Lt(val interface{}) P { return newP("lt", val) }
+
+// Lte is the predicate `P.lte(val)`
+func Lte(val interface{}) P { return newP("lte", val) }
+
+// Gt is the predicate `P.gt(val)`
+func Gt(val interface{}) P { return newP("gt", val) }
+
+// Gte is the predicate `P.gte(val)`
+func Gte(val interface{}) P { return newP("gte", val) }
+
+// Inside is the predicate `P.inside(low, high)` (exclusive)
+func Inside(low, high interface{}) P { return newP("inside", low, high) }
+
+// Outside is the predicate `P.outside(low, high)`
+func Outside(low, high interface{}) P { return newP("outside", low, high) }
+
+// Between is the predicate `P.between(low, high)` (low inclusive, high exclusive)
+func Between(low, high interface{}) P { return newP("between", low, high) }
+
+// Within is the predicate `P.within(vals...)`
+func Within(vals ...interface{}) P { return newP("within", vals...) }
+
+// Without is the predicate `P.without(vals...)`
+func Without(vals ...interface{}) P { return newP("without", vals...) }
+
+// StartingWith is the predicate `TextP.startingWith(s)`
+func StartingWith(s string) P { return newTextP("startingWith", s) }
+
+// EndingWith is the predicate `TextP.endingWith(s)`
+func EndingWith(s string) P { return newTextP("endingWith", s) }
+
+// Containing is the predicate `TextP.containing(s)`
+func Containing(s string) P { return newTextP("containing", s) }
+
+// NotStartingWith is the predicate `TextP.notStartingWith(s)`
+func NotStartingWith(s string) P { return newTextP("notStartingWith", s) }
+
+// NotEndingWith is the predicate `TextP.notEndingWith(s)`
+func NotEndingWith(s string) P { return newTextP("notEndingWith", s) }
+
+// NotContaining is the predicate `TextP.notContaining(s)`
+func NotContaining(s string) P { return newTextP("notContaining", s) }
@@ -0,0 +1,153 @@
+// Package traversal is a fluent builder for Gremlin traversals, rendering them as Gremlin-Groovy scripts
+// (with every argument safely escaped, for use with Client.ExecuteCtx) or as GraphSON (v3) bytecode.
+//
+//	query, err := traversal.G.V().HasLabel("dataset").Has("state", "published").Out("hasEdition").Limit(10).Groovy()
+//
+// Traversals are immutable: each step returns a new traversal, so a partial traversal may be reused.
+package traversal
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrorInvalidStepName      = errors.New("invalid step name")
+	ErrorUnsupportedArgument  = errors.New("unsupported argument type")
+	ErrorSpawnedChildArgument = errors.New("a child traversal must be anonymous (started with Anon) to be rendered as bytecode")
+)
+
+var stepNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Source is a traversal source, from which traversals are spawned (e.g. G.V())
+type Source string
+
+// G is the default traversal source, `g`
+const G Source = "g"
+
+// anon is the source of anonymous (child) traversals
+const anon = "__"
+
+// Step is a single step of a traversal, e.g. `has('name','cpih')`
+type Step struct {
+	Name string
+	Args []interface{}
+}
+
+// Traversal is a sequence of steps from a source, built by chaining its step methods
+type Traversal struct {
+	source string
+	steps  []Step
+	err    error
+}
+
+// Anon starts an anonymous traversal (`__`), for use as the argument of steps such as Where, Union or Repeat
+func Anon() *Traversal {
+	return &Traversal{source: anon}
+}
+
+// V spawns a traversal over the vertices (with ids, if given)
+func (s Source) V(ids ...interface{}) *Traversal {
+	return (&Traversal{source: string(s)}).add("V", ids...)
+}
+
+// E spawns a traversal over the edges (with ids, if given)
+func (s Source) E(ids ...interface{}) *Traversal {
+	return (&Traversal{source: string(s)}).add("E", ids...)
+}
+
+// AddV spawns a traversal which adds a vertex with label
+func (s Source) AddV(label string) *Traversal {
+	return (&Traversal{source: string(s)}).add("addV", label)
+}
+
+// AddE spawns a traversal which adds an edge with label (see From and To)
+func (s Source) AddE(label string) *Traversal {
+	return (&Traversal{source: string(s)}).add("addE", label)
+}
+
+// Inject spawns a traversal over vals
+func (s Source) Inject(vals ...interface{}) *Traversal {
+	return (&Traversal{source: string(s)}).add("inject", vals...)
+}
+
+// add returns a copy of t with the step appended, recording the first invalid argument as the traversal's error
+func (t *Traversal) add(name string, args ...interface{}) *Traversal {
+	nt := &Traversal{
+		source: t.source,
+		steps:  make([]Step, len(t.steps), len(t.steps)+1),
+		err:    t.err,
+	}
+	copy(nt.steps, t.steps)
+	if nt.err == nil {
+		if !stepNameRegexp.MatchString(name) {
+			nt.err = errors.Wrapf(ErrorInvalidStepName, "%q", name)
+		}
+		for _, arg := range args {
+			if nt.err != nil {
+				break
+			}
+			if err := checkArg(arg); err != nil {
+				nt.err = errors.Wrapf(err, "%s()", name)
+			}
+		}
+	}
+	nt.steps = append(nt.steps, Step{Name: name, Args: args})
+	return nt
+}
+
+// Step adds a step which is not otherwise provided by this package
+func (t *Traversal) Step(name string, args ...interface{}) *Traversal {
+	return t.add(name, args...)
+}
+
+// Steps returns the steps of the traversal
+func (t *Traversal) Steps() []Step {
+	steps := make([]Step, len(t.steps))
+	copy(steps, t.steps)
+	return steps
+}
+
+// Err returns the first error (e.g. an unsupported argument) in building the traversal
+func (t *Traversal) Err() error {
+	return t.err
+}
+
+// Groovy returns the traversal as a Gremlin-Groovy script, e.g. `g.V().hasLabel('dataset')`
+func (t *Traversal) Groovy() (string, error) {
+	if t.err != nil {
+		return "", t.err
+	}
+	var b strings.Builder
+	if err := writeGroovyTraversal(&b, t); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// String returns the traversal as a Gremlin-Groovy script (or a description of its error)
+func (t *Traversal) String() string {
+	s, err := t.Groovy()
+	if err != nil {
+		return "<invalid traversal: " + err.Error() + ">"
+	}
+	return s
+}
+
+func toArgs(strs []string) []interface{} {
+	args := make([]interface{}, len(strs))
+	for i, s := range strs {
+		args[i] = s
+	}
+	return args
+}
+
+func traversalArgs(ts []*Traversal) []interface{} {
+	args := make([]interface{}, len(ts))
+	for i, t := range ts {
+		args[i] = t
+	}
+	return args
+}
@@ -0,0 +1,120 @@
+package traversal
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+type state string
+
+var states = []state{"draft", "published"}
+
+var goldenTraversals = []struct {
+	name string
+	t    *Traversal
+}{
+	{"readme", G.V().HasLabel("x").Has("k", "v").Out("e").Limit(10)},
+	{"escaping", G.V().Has("name", "it's a \\ \"test\"\n$x ${y}\u0000\u2028")},
+	{"numbers", G.Inject(1, int64(2), int64(math.MaxInt64), int8(-3), uint16(4), 1.5, float32(0.1), math.Inf(1), math.NaN(), true, nil)},
+	{"dates", G.V().Has("at", Gte(time.Date(2021, 1, 2, 3, 4, 5, 6000000, time.FixedZone("BST", 3600))))},
+	{"predicates", G.V().Has("age", Between(18, 65)).Has("name", Within("a", "b")).Has("code", StartingWith("cpi")).Where(Neq("x"))},
+	{"tokens", G.V().Order().By("name", Desc).By(TId, Asc).Select(ColumnValues).Unfold().Count(Local)},
+	{"anonymous", G.V().Repeat(Anon().Out("next").SimplePath()).Until(Anon().HasLabel("end")).Times(5).Emit().Union(Anon().Values("a"), Anon())},
+	{"upsert", G.V("id1").Fold().Coalesce(Anon().Unfold(), Anon().AddV("dataset").Property(TId, "id1")).Property(CardinalitySingle, "title", "CPIH")},
+	{"edge", G.AddE("hasEdition").From(Anon().V("a")).To(Anon().V("b")).Property("since", int64(1600000000000))},
+	{"project", G.V().HasLabel("dataset").Project("id", "editions").By(TId).By(Anon().Out("hasEdition").Count()).Range(0, 20)},
+	{"lists", G.Inject([]string{"a", "b'"}, [2]int32{1, 2}, []interface{}{}, state("published")).Has("state", Within(states))},
+	{"custom-step", G.V().Step("valueMap", true).Step("with", "~tinkerpop.valueMap.tokens")},
+}
+
+func TestGolden(t *testing.T) {
+	for _, gt := range goldenTraversals {
+		t.Run(gt.name, func(t *testing.T) {
+			groovy, err := gt.t.Groovy()
+			if err != nil {
+				t.Fatal(err)
+			}
+			bc, err := gt.t.Bytecode()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var indented bytes.Buffer
+			if err = json.Indent(&indented, bc, "", "  "); err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, gt.name+".groovy", []byte(groovy+"\n"))
+			checkGolden(t, gt.name+".json", append(indented.Bytes(), '\n'))
+		})
+	}
+}
+
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run with -update to create)", err)
+	}
+	if !bytes.Equal(got, expected) {
+		t.Errorf("%s: expected\n%s\ngot\n%s", name, expected, got)
+	}
+}
+
+func TestImmutable(t *testing.T) {
+	base := G.V().HasLabel("dataset")
+	a := base.Out("a")
+	b := base.Out("b")
+	if base.String() != "g.V().hasLabel('dataset')" {
+		t.Errorf("Expected base to be unchanged, got %s", base)
+	}
+	if a.String() != "g.V().hasLabel('dataset').out('a')" || b.String() != "g.V().hasLabel('dataset').out('b')" {
+		t.Errorf("Expected independent traversals, got %s and %s", a, b)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	testData := []struct {
+		t        *Traversal
+		expected error
+	}{
+		{G.V().Has("k", struct{}{}), ErrorUnsupportedArgument},
+		{G.V().Has("k", Within([]map[string]int{{}})), ErrorUnsupportedArgument},
+		{G.V().Has("k", "\xff"), ErrorUnsupportedArgument},
+		{G.V().Has("k", uint64(math.MaxUint64)), ErrorUnsupportedArgument},
+		{G.V().Where((*Traversal)(nil)), ErrorUnsupportedArgument},
+		{G.V().Where(Anon().Has("k", map[string]int{})).Out(), ErrorUnsupportedArgument},
+		{G.V().Step("drop();g.V", 1), ErrorInvalidStepName},
+	}
+	for _, td := range testData {
+		if _, err := td.t.Groovy(); errors.Cause(err) != td.expected {
+			t.Errorf("Expected %v rendering Groovy, got %v", td.expected, err)
+		}
+		if _, err := td.t.Bytecode(); errors.Cause(err) != td.expected {
+			t.Errorf("Expected %v rendering bytecode, got %v", td.expected, err)
+		}
+	}
+
+	spawned := G.AddE("e").From(G.V("a"))
+	if _, err := spawned.Groovy(); err != nil {
+		t.Errorf("Expected spawned child traversal to render as Groovy, got %v", err)
+	}
+	if _, err := spawned.Bytecode(); errors.Cause(err) != ErrorSpawnedChildArgument {
+		t.Errorf("Expected %v, got %v", ErrorSpawnedChildArgument, err)
+	}
+}
@@ -0,0 +1,120 @@
+package gremgo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ONSdigital/graphson"
+	"github.com/ONSdigital/gremgo-neptune/traversal"
+	"github.com/pkg/errors"
+)
+
+var ErrorNoUpsertKey = errors.New("upsert needs an id, or properties tagged with the key option, to match on")
+
+// upsertVertexTraversal returns the traversal which adds the vertex for data, or updates the properties of the
+// existing vertex with its id (or, without an id, its "key"-tagged properties), e.g.
+//
+//	g.V('id').hasLabel('dataset').fold().coalesce(__.unfold(),__.addV('dataset').property(T.id,'id'))
+//	    .property(VertexProperty.Cardinality.single,'title','CPIH')
+//
+// Properties have single (or, for the slice options, set) cardinality, unless tagged with the option single, list or set
+func upsertVertexTraversal(label string, data interface{}) (*traversal.Traversal, error) {
+	sv, err := readStruct(data)
+	if err != nil && err != ErrorNoGraphTags {
+		return nil, err
+	}
+
+	var match *traversal.Traversal
+	create := traversal.Anon().AddV(label)
+	if sv.id != nil {
+		match = traversal.G.V(sv.id).HasLabel(label)
+		create = create.Property(traversal.TId, sv.id)
+	} else {
+		match = traversal.G.V().HasLabel(label)
+		keys := 0
+		for _, prop := range sv.props {
+			if !prop.key {
+				continue
+			}
+			if prop.list || len(prop.values) != 1 {
+				return nil, errors.Wrapf(ErrorNoUpsertKey, "key property %q must have a single value", prop.name)
+			}
+			match = match.Has(prop.name, prop.values[0])
+			keys++
+		}
+		if keys == 0 {
+			return nil, ErrorNoUpsertKey
+		}
+	}
+
+	t := match.Fold().Coalesce(traversal.Anon().Unfold(), create)
+	for _, prop := range sv.props {
+		card, ok := prop.cardinalityToken()
+		if !prop.list {
+			if !ok {
+				card = traversal.CardinalitySingle
+			}
+			for _, val := range prop.values {
+				t = t.Property(card, prop.name, val)
+			}
+			continue
+		}
+		// replace (rather than add to) the values of a multi-valued property (Neptune supports set, not list, cardinality)
+		if !ok {
+			card = traversal.CardinalitySet
+		}
+		t = t.SideEffect(traversal.Anon().Properties(prop.name).Drop())
+		for _, val := range prop.values {
+			t = t.Property(card, prop.name, val)
+		}
+	}
+	return t, t.Err()
+}
+
+// UpsertVertexCtx adds the vertex for data (a struct with `graph` tags, as for AddVertexCtx), or else updates the
+// properties of the existing vertex with its id, or (without an id) with the same values of the properties
+// tagged with the key option (e.g. `graph:"name,string,key"`), returning the resulting vertex
+func (c *Client) UpsertVertexCtx(ctx context.Context, label string, data interface{}) (vert graphson.Vertex, err error) {
+	if c.conn.IsDisposed() {
+		return vert, ErrorConnectionDisposed
+	}
+
+	var t *traversal.Traversal
+	if t, err = upsertVertexTraversal(label, data); err != nil {
+		return
+	}
+	var q string
+	if q, err = t.Groovy(); err != nil {
+		return
+	}
+
+	var resp []Response
+	if resp, err = c.ExecuteCtx(ctx, q, nil, nil); err != nil {
+		return
+	}
+	var vertices []graphson.Vertex
+	for _, res := range resp {
+		if res.Status.Code == StatusNoContent {
+			continue
+		}
+		var result []graphson.Vertex
+		if result, err = graphson.DeserializeListOfVerticesFromBytes(res.Result.Data); err != nil {
+			return
+		}
+		vertices = append(vertices, result...)
+	}
+	if len(vertices) != 1 {
+		return vert, fmt.Errorf("UpsertVertexCtx should receive 1 vertex, got %d", len(vertices))
+	}
+	return vertices[0], nil
+}
+
+// UpsertVertexCtx adds or updates the vertex for data (see Client.UpsertVertexCtx)
+func (p *Pool) UpsertVertexCtx(ctx context.Context, label string, data interface{}) (vert graphson.Vertex, err error) {
+	var pc *conn
+	if pc, err = p.connCtx(ctx); err != nil {
+		return vert, errors.Wrap(err, "UpsertVertexCtx: Failed p.connCtx")
+	}
+	defer p.putConn(pc, err)
+	return pc.Client.UpsertVertexCtx(ctx, label, data)
+}
@@ -0,0 +1,112 @@
+package gremgo
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestUpsertVertexTraversal(t *testing.T) {
+	type dataset struct {
+		Id    string
+		Title string   `graph:"title,string"`
+		Count int      `graph:"count,number"`
+		Tags  []string `graph:"tags,[]string"`
+	}
+	type keyed struct {
+		Code  string `graph:"code,string,key"`
+		Title string `graph:"title,string"`
+	}
+	type unkeyed struct {
+		Title string `graph:"title,string"`
+	}
+	type cardinality struct {
+		Id    string
+		Seen  string   `graph:"seen,string,set"`
+		Notes []string `graph:"notes,[]string,list"`
+	}
+	type listKey struct {
+		Codes []string `graph:"codes,[]string,key"`
+	}
+
+	testData := []struct {
+		data     interface{}
+		expected string
+	}{
+		{
+			dataset{Id: "cpih", Title: "CPIH's", Count: 2, Tags: []string{"a", "b"}},
+			`g.V('cpih').hasLabel('dataset').fold().coalesce(__.unfold(),__.addV('dataset').property(T.id,'cpih'))` +
+				`.property(VertexProperty.Cardinality.single,'title','CPIH\'s').property(VertexProperty.Cardinality.single,'count',2)` +
+				`.sideEffect(__.properties('tags').drop()).property(VertexProperty.Cardinality.set,'tags','a').property(VertexProperty.Cardinality.set,'tags','b')`,
+		},
+		{
+			&keyed{Code: "x1", Title: "X"},
+			`g.V().hasLabel('dataset').has('code','x1').fold().coalesce(__.unfold(),__.addV('dataset'))` +
+				`.property(VertexProperty.Cardinality.single,'code','x1').property(VertexProperty.Cardinality.single,'title','X')`,
+		},
+		{
+			cardinality{Id: "c", Seen: "today", Notes: []string{"n"}},
+			`g.V('c').hasLabel('dataset').fold().coalesce(__.unfold(),__.addV('dataset').property(T.id,'c'))` +
+				`.property(VertexProperty.Cardinality.set,'seen','today')` +
+				`.sideEffect(__.properties('notes').drop()).property(VertexProperty.Cardinality.list,'notes','n')`,
+		},
+	}
+	for _, td := range testData {
+		tr, err := upsertVertexTraversal("dataset", td.data)
+		if err != nil {
+			t.Errorf("%+v: %v", td.data, err)
+			continue
+		}
+		if q := tr.String(); q != td.expected {
+			t.Errorf("%+v: expected\n%s\ngot\n%s", td.data, td.expected, q)
+		}
+	}
+
+	for _, data := range []interface{}{unkeyed{Title: "x"}, keyed{Title: "no code"}, listKey{Codes: []string{"a"}}} {
+		if _, err := upsertVertexTraversal("dataset", data); errors.Cause(err) != ErrorNoUpsertKey {
+			t.Errorf("%+v: expected %v, got %v", data, ErrorNoUpsertKey, err)
+		}
+	}
+	if _, err := upsertVertexTraversal("dataset", "x"); err != ErrorNotStruct {
+		t.Errorf("Expected %v, got %v", ErrorNotStruct, err)
+	}
+}
+
+func TestUpsertVertexCtx(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	type dataset struct {
+		Id    string
+		Title string `graph:"title,string"`
+	}
+
+	c := newChunksClient()
+	sent := make(chan request, 1)
+	go func() {
+		msg := <-c.requests
+		var req request
+		if err := json.Unmarshal(msg[len(mimeTypePrefix):], &req); err != nil {
+			t.Error(err)
+		}
+		sent <- req
+		c.saveResponse(Response{RequestID: req.RequestID, Status: Status{Code: StatusSuccess}, Result: Result{Data: json.RawMessage(
+			`{"@type":"g:List","@value":[{"@type":"g:Vertex","@value":{"id":"cpih","label":"dataset","properties":{"title":[{"@type":"g:VertexProperty","@value":{"id":{"@type":"Type","@value":1},"value":"CPIH","label":"title"}}]}}}]}`,
+		)}}, nil)
+	}()
+
+	vert, err := c.UpsertVertexCtx(ctx, "dataset", dataset{Id: "cpih", Title: "CPIH"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vert.Value.ID != "cpih" || vert.Value.Label != "dataset" {
+		t.Errorf("Expected the upserted vertex, got %+v", vert)
+	}
+	req := <-sent
+	if q := req.Args["gremlin"]; q != `g.V('cpih').hasLabel('dataset').fold().coalesce(__.unfold(),__.addV('dataset').property(T.id,'cpih')).property(VertexProperty.Cardinality.single,'title','CPIH')` {
+		t.Errorf("Unexpected query %s", q)
+	}
+	assertNoRequestState(t, c)
+}
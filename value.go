@@ -0,0 +1,648 @@
+package gremgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+
+	"github.com/ONSdigital/graphson"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrorValueNotFound        = errors.New("value not found")
+	ErrorUnexpectedValueType  = errors.New("unexpected value type")
+	ErrorMalformedGraphSONMap = errors.New("malformed graphson map: odd number of items")
+)
+
+// List is a decoded g:List
+type List []interface{}
+
+// Set is a decoded g:Set
+type Set []interface{}
+
+// Map is a decoded g:Map - the keys may be of any decoded type (e.g. a T for `id` from elementMap()),
+// so it is held as its entries, in the order they were returned
+type Map []MapEntry
+
+// MapEntry is a key and value in a Map
+type MapEntry struct {
+	Key   interface{}
+	Value interface{}
+}
+
+// BulkSet is a decoded g:BulkSet, holding each value with the number of times it occurs
+type BulkSet []BulkSetEntry
+
+// BulkSetEntry is a value, and its count, in a BulkSet
+type BulkSetEntry struct {
+	Value interface{}
+	Bulk  int64
+}
+
+// Path is a decoded g:Path, the Labels for each step correspond to the Objects
+type Path struct {
+	Labels  [][]string
+	Objects List
+}
+
+// Tree is a decoded g:Tree (as returned by tree()), holding each root with the tree of its children
+type Tree []TreeEntry
+
+// TreeEntry is a root (e.g. a vertex, or a value of tree().by('name')), and its children, in a Tree
+type TreeEntry struct {
+	Key   interface{}
+	Value Tree
+}
+
+// Property is a decoded g:Property (e.g. an edge property)
+type Property struct {
+	Key   string
+	Value interface{}
+}
+
+// T is a decoded g:T (a token for the `id`, `label`, `key` or `value` of an element)
+type T string
+
+// Direction is a decoded g:Direction (as used as a key by elementMap() for edges)
+type Direction string
+
+const (
+	TId    T = "id"
+	TLabel T = "label"
+	TKey   T = "key"
+	TValue T = "value"
+
+	DirectionOut  Direction = "OUT"
+	DirectionIn   Direction = "IN"
+	DirectionBoth Direction = "BOTH"
+)
+
+// TypedValue is a decoded value of a GraphSON type which is not otherwise recognised
+type TypedValue struct {
+	Type  string
+	Value interface{}
+}
+
+// graphsonTyped is the wrapper for any GraphSON typed value
+type graphsonTyped struct {
+	Type  string          `json:"@type"`
+	Value json.RawMessage `json:"@value"`
+}
+
+// typeDecoder decodes the `@value` of a (non-scalar) GraphSON type, raw is the whole typed value (including `@type`)
+type typeDecoder func(value, raw json.RawMessage) (interface{}, error)
+
+// typeDecoders are the collection and element types, scalars are in scalarDecoders
+var typeDecoders map[string]typeDecoder
+
+func init() {
+	typeDecoders = map[string]typeDecoder{
+		"g:List":    decodeGraphSONList,
+		"g:Set":     decodeGraphSONSet,
+		"g:Map":     decodeGraphSONMap,
+		"g:BulkSet": decodeGraphSONBulkSet,
+		"g:Path":    decodeGraphSONPath,
+		"g:Tree":    decodeGraphSONTree,
+		"g:T": func(value, raw json.RawMessage) (interface{}, error) {
+			var t string
+			err := json.Unmarshal(value, &t)
+			return T(t), err
+		},
+		"g:Direction": func(value, raw json.RawMessage) (interface{}, error) {
+			var d string
+			err := json.Unmarshal(value, &d)
+			return Direction(d), err
+		},
+		"g:Property": decodeGraphSONProperty,
+		"g:Vertex": func(value, raw json.RawMessage) (interface{}, error) {
+			var v graphson.Vertex
+			err := json.Unmarshal(raw, &v)
+			return v, err
+		},
+		"g:Edge": func(value, raw json.RawMessage) (interface{}, error) {
+			var e graphson.Edge
+			err := json.Unmarshal(raw, &e)
+			return e, err
+		},
+		"g:VertexProperty": func(value, raw json.RawMessage) (interface{}, error) {
+			var vp graphson.VertexProperty
+			err := json.Unmarshal(raw, &vp)
+			return vp, err
+		},
+	}
+}
+
+// DecodeGraphSON decodes the GraphSON (v3) in raw into a tree of values,
+// using List, Set, Map, BulkSet, Path, Tree, Property, T and Direction for those types,
+// graphson.Vertex, graphson.Edge and graphson.VertexProperty for elements,
+// and Go types for scalars (see RegisterType)
+func DecodeGraphSON(raw []byte) (val interface{}, err error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	switch raw[0] {
+	case '{':
+		var typed graphsonTyped
+		if err = json.Unmarshal(raw, &typed); err != nil {
+			return
+		}
+		if typed.Type == "" {
+			return decodeGraphSONObject(raw)
+		}
+		if decoder, ok := typeDecoders[typed.Type]; ok {
+			val, err = decoder(typed.Value, raw)
+		} else if scalarDecoder := lookupScalarDecoder(typed.Type); scalarDecoder != nil {
+			val, err = scalarDecoder(typed.Value)
+		} else {
+			var typedVal TypedValue
+			typedVal.Type = typed.Type
+			typedVal.Value, err = DecodeGraphSON(typed.Value)
+			return typedVal, err
+		}
+		if err != nil {
+			err = errors.Wrapf(err, "decoding %s", typed.Type)
+		}
+		return
+	case '[':
+		return decodeGraphSONArray(raw)
+	default:
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		err = dec.Decode(&val)
+		return
+	}
+}
+
+// decodeGraphSONArray decodes an untyped JSON array of GraphSON values
+func decodeGraphSONArray(raw json.RawMessage) (vals List, err error) {
+	var items []json.RawMessage
+	if err = json.Unmarshal(raw, &items); err != nil {
+		return
+	}
+	vals = make(List, len(items))
+	for i, item := range items {
+		if vals[i], err = DecodeGraphSON(item); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+// decodeGraphSONObject decodes an untyped JSON object as a Map (with string keys, in no particular order)
+func decodeGraphSONObject(raw json.RawMessage) (m Map, err error) {
+	var obj map[string]json.RawMessage
+	if err = json.Unmarshal(raw, &obj); err != nil {
+		return
+	}
+	for k, v := range obj {
+		entry := MapEntry{Key: k}
+		if entry.Value, err = DecodeGraphSON(v); err != nil {
+			return nil, err
+		}
+		m = append(m, entry)
+	}
+	return
+}
+
+func decodeGraphSONList(value, raw json.RawMessage) (interface{}, error) {
+	return decodeGraphSONArray(value)
+}
+
+func decodeGraphSONSet(value, raw json.RawMessage) (interface{}, error) {
+	vals, err := decodeGraphSONArray(value)
+	return Set(vals), err
+}
+
+func decodeGraphSONMap(value, raw json.RawMessage) (interface{}, error) {
+	vals, err := decodeGraphSONArray(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(vals)%2 != 0 {
+		return nil, ErrorMalformedGraphSONMap
+	}
+	m := make(Map, 0, len(vals)/2)
+	for i := 0; i < len(vals); i += 2 {
+		m = append(m, MapEntry{Key: vals[i], Value: vals[i+1]})
+	}
+	return m, nil
+}
+
+func decodeGraphSONBulkSet(value, raw json.RawMessage) (interface{}, error) {
+	vals, err := decodeGraphSONArray(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(vals)%2 != 0 {
+		return nil, ErrorMalformedGraphSONMap
+	}
+	bs := make(BulkSet, 0, len(vals)/2)
+	for i := 0; i < len(vals); i += 2 {
+		bulk, err := ToInt64(vals[i+1])
+		if err != nil {
+			return nil, err
+		}
+		bs = append(bs, BulkSetEntry{Value: vals[i], Bulk: bulk})
+	}
+	return bs, nil
+}
+
+func decodeGraphSONPath(value, raw json.RawMessage) (interface{}, error) {
+	var p struct {
+		Labels  json.RawMessage `json:"labels"`
+		Objects json.RawMessage `json:"objects"`
+	}
+	if err := json.Unmarshal(value, &p); err != nil {
+		return nil, err
+	}
+	var path Path
+	labels, err := DecodeGraphSON(p.Labels)
+	if err != nil {
+		return nil, err
+	}
+	labelsList, _ := labels.(List)
+	for _, stepLabels := range labelsList {
+		var strs []string
+		if strs, err = toStrings(stepLabels); err != nil {
+			return nil, err
+		}
+		path.Labels = append(path.Labels, strs)
+	}
+	objects, err := DecodeGraphSON(p.Objects)
+	if err != nil {
+		return nil, err
+	}
+	path.Objects, _ = objects.(List)
+	return path, nil
+}
+
+func decodeGraphSONTree(value, raw json.RawMessage) (interface{}, error) {
+	var entries []struct {
+		Key   json.RawMessage `json:"key"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(value, &entries); err != nil {
+		return nil, err
+	}
+	tree := make(Tree, len(entries))
+	for i, entry := range entries {
+		var err error
+		if tree[i].Key, err = DecodeGraphSON(entry.Key); err != nil {
+			return nil, err
+		}
+		children, err := DecodeGraphSON(entry.Value)
+		if err != nil {
+			return nil, err
+		}
+		if children != nil {
+			var ok bool
+			if tree[i].Value, ok = children.(Tree); !ok {
+				return nil, unexpectedType("g:Tree", children)
+			}
+		}
+	}
+	return tree, nil
+}
+
+func decodeGraphSONProperty(value, raw json.RawMessage) (interface{}, error) {
+	var p struct {
+		Key   string          `json:"key"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(value, &p); err != nil {
+		return nil, err
+	}
+	val, err := DecodeGraphSON(p.Value)
+	return Property{Key: p.Key, Value: val}, err
+}
+
+// DecodeResponses decodes and concatenates the g:List results of resp (as returned for a query) into a List
+func DecodeResponses(resp []Response) (res List, err error) {
+	for _, item := range resp {
+		if item.Status.Code == StatusNoContent {
+			continue
+		}
+		var val interface{}
+		if val, err = DecodeGraphSON(item.Result.Data); err != nil {
+			return nil, err
+		}
+		if val == nil {
+			continue
+		}
+		list, ok := val.(List)
+		if !ok {
+			return nil, errors.Wrapf(ErrorUnexpectedValueType, "expected g:List result, got %T", val)
+		}
+		res = append(res, list...)
+	}
+	return
+}
+
+// Get returns the value for key (compared by value, so key may be of any decoded type, e.g. TId)
+func (m Map) Get(key interface{}) (interface{}, bool) {
+	for _, entry := range m {
+		if reflect.DeepEqual(entry.Key, key) {
+			return entry.Value, true
+		}
+	}
+	return nil, false
+}
+
+// StringMap returns m as a map with string keys (T and Direction keys become their names)
+func (m Map) StringMap() (map[string]interface{}, error) {
+	res := make(map[string]interface{}, len(m))
+	for _, entry := range m {
+		key, err := ToString(entry.Key)
+		if err != nil {
+			return nil, errors.Wrap(err, "map key")
+		}
+		res[key] = entry.Value
+	}
+	return res, nil
+}
+
+func (m Map) getSingle(key interface{}) (interface{}, error) {
+	val, ok := m.Get(key)
+	if !ok {
+		return nil, errors.Wrapf(ErrorValueNotFound, "key %v", key)
+	}
+	// valueMap() returns a list of values for each property
+	if list, ok := val.(List); ok && len(list) == 1 {
+		return list[0], nil
+	}
+	return val, nil
+}
+
+// GetString returns the value for key as a string, unwrapping a single-valued list (as returned by valueMap())
+func (m Map) GetString(key interface{}) (string, error) {
+	val, err := m.getSingle(key)
+	if err != nil {
+		return "", err
+	}
+	return ToString(val)
+}
+
+// GetInt64 returns the value for key as an int64, unwrapping a single-valued list (as returned by valueMap())
+func (m Map) GetInt64(key interface{}) (int64, error) {
+	val, err := m.getSingle(key)
+	if err != nil {
+		return 0, err
+	}
+	return ToInt64(val)
+}
+
+// GetFloat64 returns the value for key as a float64, unwrapping a single-valued list (as returned by valueMap())
+func (m Map) GetFloat64(key interface{}) (float64, error) {
+	val, err := m.getSingle(key)
+	if err != nil {
+		return 0, err
+	}
+	return ToFloat64(val)
+}
+
+// GetBool returns the value for key as a bool, unwrapping a single-valued list (as returned by valueMap())
+func (m Map) GetBool(key interface{}) (bool, error) {
+	val, err := m.getSingle(key)
+	if err != nil {
+		return false, err
+	}
+	b, ok := val.(bool)
+	if !ok {
+		return false, unexpectedType("bool", val)
+	}
+	return b, nil
+}
+
+// GetList returns the value for key as a List (a Set or BulkSet is converted)
+func (m Map) GetList(key interface{}) (List, error) {
+	val, ok := m.Get(key)
+	if !ok {
+		return nil, errors.Wrapf(ErrorValueNotFound, "key %v", key)
+	}
+	return ToList(val)
+}
+
+// GetMap returns the value for key as a Map
+func (m Map) GetMap(key interface{}) (Map, error) {
+	val, ok := m.Get(key)
+	if !ok {
+		return nil, errors.Wrapf(ErrorValueNotFound, "key %v", key)
+	}
+	res, ok := val.(Map)
+	if !ok {
+		return nil, unexpectedType("g:Map", val)
+	}
+	return res, nil
+}
+
+// Expand returns the values of the BulkSet, each repeated by its bulk
+func (bs BulkSet) Expand() (res List) {
+	for _, entry := range bs {
+		for i := int64(0); i < entry.Bulk; i++ {
+			res = append(res, entry.Value)
+		}
+	}
+	return
+}
+
+// Maps returns the items of the list as Maps (e.g. for valueMap(), elementMap(), project() or group())
+func (l List) Maps() ([]Map, error) {
+	res := make([]Map, len(l))
+	for i, item := range l {
+		m, ok := item.(Map)
+		if !ok {
+			return nil, unexpectedType("g:Map", item)
+		}
+		res[i] = m
+	}
+	return res, nil
+}
+
+// Paths returns the items of the list as Paths
+func (l List) Paths() ([]Path, error) {
+	res := make([]Path, len(l))
+	for i, item := range l {
+		p, ok := item.(Path)
+		if !ok {
+			return nil, unexpectedType("g:Path", item)
+		}
+		res[i] = p
+	}
+	return res, nil
+}
+
+// Trees returns the items of the list as Trees
+func (l List) Trees() ([]Tree, error) {
+	res := make([]Tree, len(l))
+	for i, item := range l {
+		t, ok := item.(Tree)
+		if !ok {
+			return nil, unexpectedType("g:Tree", item)
+		}
+		res[i] = t
+	}
+	return res, nil
+}
+
+// Get returns the children of the root key of the tree (compared as for Map.Get)
+func (t Tree) Get(key interface{}) (Tree, bool) {
+	for _, entry := range t {
+		if reflect.DeepEqual(entry.Key, key) {
+			return entry.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Vertices returns the items of the list as vertices
+func (l List) Vertices() ([]graphson.Vertex, error) {
+	res := make([]graphson.Vertex, len(l))
+	for i, item := range l {
+		v, ok := item.(graphson.Vertex)
+		if !ok {
+			return nil, unexpectedType("g:Vertex", item)
+		}
+		res[i] = v
+	}
+	return res, nil
+}
+
+// Edges returns the items of the list as edges
+func (l List) Edges() ([]graphson.Edge, error) {
+	res := make([]graphson.Edge, len(l))
+	for i, item := range l {
+		e, ok := item.(graphson.Edge)
+		if !ok {
+			return nil, unexpectedType("g:Edge", item)
+		}
+		res[i] = e
+	}
+	return res, nil
+}
+
+// Strings returns the items of the list as strings
+func (l List) Strings() ([]string, error) {
+	return toStrings(l)
+}
+
+// Int64s returns the items of the list as int64s
+func (l List) Int64s() ([]int64, error) {
+	res := make([]int64, len(l))
+	for i, item := range l {
+		var err error
+		if res[i], err = ToInt64(item); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func toStrings(val interface{}) ([]string, error) {
+	list, err := ToList(val)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]string, len(list))
+	for i, item := range list {
+		if res[i], err = ToString(item); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// ToList returns val as a List, converting a Set or BulkSet
+func ToList(val interface{}) (List, error) {
+	switch v := val.(type) {
+	case List:
+		return v, nil
+	case Set:
+		return List(v), nil
+	case BulkSet:
+		return v.Expand(), nil
+	}
+	return nil, unexpectedType("g:List", val)
+}
+
+// ToString returns val as a string (including T and Direction values)
+func ToString(val interface{}) (string, error) {
+	switch v := val.(type) {
+	case string:
+		return v, nil
+	case T:
+		return string(v), nil
+	case Direction:
+		return string(v), nil
+	}
+	return "", unexpectedType("string", val)
+}
+
+// ToInt64 returns val as an int64, for any integer value (or a whole float)
+func ToInt64(val interface{}) (int64, error) {
+	switch v := val.(type) {
+	case int8:
+		return int64(v), nil
+	case int16:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case json.Number:
+		return v.Int64()
+	case float64:
+		if v == math.Trunc(v) && v >= math.MinInt64 && v < math.MaxInt64 {
+			return int64(v), nil
+		}
+	case float32:
+		if f := float64(v); f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+			return int64(v), nil
+		}
+	case *big.Int:
+		if v.IsInt64() {
+			return v.Int64(), nil
+		}
+	case *big.Float:
+		if i, acc := v.Int64(); acc == big.Exact {
+			return i, nil
+		}
+	}
+	return 0, unexpectedType("integer", val)
+}
+
+// ToFloat64 returns val as a float64, for any numeric value
+func ToFloat64(val interface{}) (float64, error) {
+	switch v := val.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int8:
+		return float64(v), nil
+	case int16:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case json.Number:
+		return v.Float64()
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(v).Float64()
+		return f, nil
+	case *big.Float:
+		f, _ := v.Float64()
+		return f, nil
+	}
+	return 0, unexpectedType("number", val)
+}
+
+func unexpectedType(expected string, val interface{}) error {
+	return errors.Wrap(ErrorUnexpectedValueType, fmt.Sprintf("expected %s, got %T", expected, val))
+}
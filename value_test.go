@@ -0,0 +1,231 @@
+package gremgo
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// g.V().hasLabel('dataset').elementMap()
+const dummyElementMaps = `{"@type":"g:List","@value":[` +
+	`{"@type":"g:Map","@value":[` +
+	`{"@type":"g:T","@value":"id"},"v1",` +
+	`{"@type":"g:T","@value":"label"},"dataset",` +
+	`"name","cpih",` +
+	`"count",{"@type":"g:Int64","@value":9007199254740993}` +
+	`]}]}`
+
+// g.V('v1').outE().elementMap()
+const dummyEdgeElementMaps = `{"@type":"g:List","@value":[` +
+	`{"@type":"g:Map","@value":[` +
+	`{"@type":"g:T","@value":"id"},"e1",` +
+	`{"@type":"g:T","@value":"label"},"hasEdition",` +
+	`{"@type":"g:Direction","@value":"IN"},{"@type":"g:Map","@value":[{"@type":"g:T","@value":"id"},"v2",{"@type":"g:T","@value":"label"},"edition"]},` +
+	`{"@type":"g:Direction","@value":"OUT"},{"@type":"g:Map","@value":[{"@type":"g:T","@value":"id"},"v1",{"@type":"g:T","@value":"label"},"dataset"]}` +
+	`]}]}`
+
+// g.V('v1').out().path().by('name')
+const dummyPaths = `{"@type":"g:List","@value":[` +
+	`{"@type":"g:Path","@value":{` +
+	`"labels":{"@type":"g:List","@value":[{"@type":"g:Set","@value":["a"]},{"@type":"g:Set","@value":[]}]},` +
+	`"objects":{"@type":"g:List","@value":["cpih","2021"]}}}]}`
+
+// g.V().values('name').aggregate('x').cap('x')
+const dummyBulkSet = `{"@type":"g:List","@value":[` +
+	`{"@type":"g:BulkSet","@value":["a",{"@type":"g:Int64","@value":2},"b",{"@type":"g:Int64","@value":1}]}]}`
+
+// g.V('v1').out().out().tree().by('name')
+const dummyTree = `{"@type":"g:List","@value":[{"@type":"g:Tree","@value":[` +
+	`{"key":"cpih","value":{"@type":"g:Tree","@value":[` +
+	`{"key":"2021","value":{"@type":"g:Tree","@value":[{"key":"v1","value":{"@type":"g:Tree","@value":[]}},{"key":"v2","value":{"@type":"g:Tree","@value":[]}}]}}` +
+	`]}}]}]}`
+
+func TestDecodeGraphSON(t *testing.T) {
+	t.Run("element map", func(t *testing.T) {
+		val, err := DecodeGraphSON([]byte(dummyElementMaps))
+		if err != nil {
+			t.Fatal(err)
+		}
+		maps, err := val.(List).Maps()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := Map{
+			{Key: TId, Value: "v1"},
+			{Key: TLabel, Value: "dataset"},
+			{Key: "name", Value: "cpih"},
+			{Key: "count", Value: int64(9007199254740993)},
+		}
+		if !reflect.DeepEqual(maps, []Map{expected}) {
+			t.Errorf("Expected %#v\n got %#v", expected, maps)
+		}
+		if id, err := maps[0].GetString(TId); err != nil || id != "v1" {
+			t.Errorf("Expected id v1, got %q %v", id, err)
+		}
+		if count, err := maps[0].GetInt64("count"); err != nil || count != 9007199254740993 {
+			t.Errorf("Expected exact count, got %d %v", count, err)
+		}
+		if _, err := maps[0].GetString("missing"); !errors.Is(err, ErrorValueNotFound) {
+			t.Errorf("Expected %v, got %v", ErrorValueNotFound, err)
+		}
+		if _, err := maps[0].GetString("count"); !errors.Is(err, ErrorUnexpectedValueType) {
+			t.Errorf("Expected %v, got %v", ErrorUnexpectedValueType, err)
+		}
+		sm, err := maps[0].StringMap()
+		if err != nil || sm["id"] != "v1" || sm["label"] != "dataset" {
+			t.Errorf("Unexpected string map %v %v", sm, err)
+		}
+	})
+
+	t.Run("edge element map", func(t *testing.T) {
+		val, err := DecodeGraphSON([]byte(dummyEdgeElementMaps))
+		if err != nil {
+			t.Fatal(err)
+		}
+		maps, err := val.(List).Maps()
+		if err != nil {
+			t.Fatal(err)
+		}
+		in, err := maps[0].GetMap(DirectionIn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id, _ := in.GetString(TId); id != "v2" {
+			t.Errorf("Expected in vertex v2, got %q", id)
+		}
+	})
+
+	t.Run("path", func(t *testing.T) {
+		val, err := DecodeGraphSON([]byte(dummyPaths))
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths, err := val.(List).Paths()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := Path{Labels: [][]string{{"a"}, {}}, Objects: List{"cpih", "2021"}}
+		if !reflect.DeepEqual(paths, []Path{expected}) {
+			t.Errorf("Expected %#v\n got %#v", expected, paths)
+		}
+	})
+
+	t.Run("tree", func(t *testing.T) {
+		val, err := DecodeGraphSON([]byte(dummyTree))
+		if err != nil {
+			t.Fatal(err)
+		}
+		trees, err := val.(List).Trees()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := Tree{{Key: "cpih", Value: Tree{{Key: "2021", Value: Tree{{Key: "v1", Value: Tree{}}, {Key: "v2", Value: Tree{}}}}}}}
+		if !reflect.DeepEqual(trees, []Tree{expected}) {
+			t.Errorf("Expected %#v\n got %#v", expected, trees)
+		}
+		editions, ok := trees[0].Get("cpih")
+		if !ok {
+			t.Fatal("Expected the root cpih")
+		}
+		if versions, ok := editions.Get("2021"); !ok || len(versions) != 2 || versions[1].Key != "v2" {
+			t.Errorf("Unexpected children %v", editions)
+		}
+		if _, ok := trees[0].Get("missing"); ok {
+			t.Error("Expected no root missing")
+		}
+	})
+
+	t.Run("bulk set", func(t *testing.T) {
+		val, err := DecodeGraphSON([]byte(dummyBulkSet))
+		if err != nil {
+			t.Fatal(err)
+		}
+		strs, err := val.(List)[0].(BulkSet).Expand().Strings()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(strs, []string{"a", "a", "b"}) {
+			t.Errorf("Unexpected expansion %v", strs)
+		}
+	})
+
+	t.Run("elements and scalars", func(t *testing.T) {
+		val, err := DecodeGraphSON([]byte(dummyDecodeVertices))
+		if err != nil {
+			t.Fatal(err)
+		}
+		verts, err := val.(List).Vertices()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(verts) != 2 || verts[0].GetID() != "v1" {
+			t.Errorf("Unexpected vertices %+v", verts)
+		}
+
+		val, err = DecodeGraphSON([]byte(`{"@type":"g:List","@value":[` +
+			`{"@type":"g:Int32","@value":1},{"@type":"g:Double","@value":1.5},true,null,` +
+			`{"@type":"g:Property","@value":{"key":"since","value":{"@type":"g:Int32","@value":2020}}},` +
+			`{"@type":"x:Unknown","@value":"raw"}]}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := List{int32(1), 1.5, true, nil, Property{Key: "since", Value: int32(2020)}, TypedValue{Type: "x:Unknown", Value: "raw"}}
+		if !reflect.DeepEqual(val, expected) {
+			t.Errorf("Expected %#v\n got %#v", expected, val)
+		}
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		for _, data := range []string{
+			`{"@type":"g:Map","@value":["a"]}`,
+			`{"@type":"g:Int32","@value":"one"}`,
+			`{"@type":"g:List","@value":[`,
+		} {
+			if _, err := DecodeGraphSON([]byte(data)); err == nil {
+				t.Errorf("Expected error decoding %s", data)
+			}
+		}
+	})
+}
+
+func TestQueryCtx(t *testing.T) {
+	errs := make(chan error)
+	p, _ := MockNewPoolWithDialerCtx(context.Background(), "ws://0", errs, t, nil, []StaggeredResponse{
+		{
+			response: Response{
+				Status: Status{Code: StatusPartialContent},
+				Result: Result{Data: json.RawMessage(dummyElementMaps)},
+			},
+		},
+		{
+			response: Response{
+				Status: Status{Code: StatusSuccess},
+				Result: Result{Data: json.RawMessage(dummyEdgeElementMaps)},
+			},
+		},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := p.QueryCtx(ctx, "g.V().elementMap()", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	maps, err := res.Maps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(maps) != 2 {
+		t.Fatalf("Expected results of both responses, got %v", maps)
+	}
+	if label, _ := maps[1].GetString(TLabel); label != "hasEdition" {
+		t.Errorf("Expected label hasEdition, got %q", label)
+	}
+	if _, err := res.Vertices(); !errors.Is(err, ErrorUnexpectedValueType) {
+		t.Errorf("Expected %v, got %v", ErrorUnexpectedValueType, err)
+	}
+}